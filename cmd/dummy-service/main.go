@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,10 +13,16 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/zhenisduissekov/another-dummy-service/internal/config"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
 	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/filestore"
 	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
 	"github.com/zhenisduissekov/another-dummy-service/internal/services"
 	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/graphql"
+	grpctransport "github.com/zhenisduissekov/another-dummy-service/internal/transport/grpc"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/openapi"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -27,34 +35,82 @@ func run() error {
 	// read config from env
 	cfg := config.Read()
 
+	validation, err := domain.ParseValidationMode(cfg.ValidationMode)
+	if err != nil {
+		return err
+	}
+
 	// create port repository
-	portStoreRepo := inmem.NewPortStore()
+	portStoreRepo, closeRepo, err := newPortRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeRepo(); err != nil {
+			log.Errorf("could not close port repository: %v", err)
+		}
+	}()
+
+	// purge deleted ports once their retention is over
+	purger := services.NewPurger(portStoreRepo, cfg.PurgeRetention, cfg.PurgeInterval)
+	purger.Start()
+	defer purger.Close()
 
 	// create port service
 	portService := services.NewPortService(portStoreRepo)
+	portService.Subscribe(func(_ context.Context, event domain.Event) {
+		log.Infof("port %s: %s", event.PortId(), event.EventName())
+	})
+
+	// deliver the changes of the ports to the registered webhooks
+	webhookClient, err := services.NewWebhookClient(cfg.WebhookTimeout, cfg.WebhookAllowedNetworks)
+	if err != nil {
+		return err
+	}
+	webhooks := services.NewWebhooks(portService, transport.EncodePortChange, webhookClient, services.RetryPolicy{
+		Attempts:   cfg.WebhookAttempts,
+		Backoff:    cfg.WebhookBackoff,
+		MaxBackoff: cfg.WebhookMaxBackoff,
+	})
+	webhooks.Start()
+	defer webhooks.Close()
 
 	// create http server with application injected
-	httpServer := transport.NewHttpServer(portService)
+	httpServer := transport.NewHttpServer(portService, validation)
+	// upload jobs are cancelled on shutdown, and waited for before the
+	// repository is closed
+	defer httpServer.CloseJobs()
+	webhookServer := transport.NewWebhookServer(webhooks)
 
 	// create http router
-	router := mux.NewRouter()
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode("health OK")
-	}).Methods(http.MethodGet)
-	router.HandleFunc("/port", httpServer.GetPort).Methods(http.MethodGet)
-	router.HandleFunc("/count", httpServer.CountPorts).Methods(http.MethodGet)
-	router.HandleFunc("/ports", httpServer.UploadPorts).Methods(http.MethodPost)
-	router.HandleFunc("/ports/{id}", httpServer.DeletePortsById).Methods(http.MethodDelete)
-	router.HandleFunc("/ports", httpServer.DeleteAllPorts).Methods(http.MethodDelete)
+	router := newRouter(httpServer, webhookServer, graphql.NewHandler(portService, validation))
 
 	srv := &http.Server{
 		Addr:              cfg.Port,
 		Handler:           router,
 		ReadHeaderTimeout: 10 * time.Second, // Set a reasonable timeout
 	}
+	// streams of changes never end by themselves, let them go on shutdown
+	srv.RegisterOnShutdown(portService.CloseChanges)
+	srv.RegisterOnShutdown(httpServer.CloseJobs)
+
+	// create grpc server serving the same service on a second listener
+	grpcServer := grpctransport.NewGrpcServer(portService, validation)
+	grpcListener, err := net.Listen("tcp", cfg.GrpcPort)
+	if err != nil {
+		return fmt.Errorf("could not listen for gRPC on %s: %w", cfg.GrpcPort, err)
+	}
+
+	go func() {
+		log.Infof("Starting gRPC server on %s", cfg.GrpcPort)
+
+		err := grpcServer.Serve(grpcListener)
+		if err != nil {
+			log.Errorf("gRPC server Serve Error: %v", err)
+		}
+	}()
 
-	// listen to OS signals and gracefully shutdown HTTP server
+	// listen to OS signals and gracefully shutdown HTTP and gRPC servers
 	stopped := make(chan struct{})
 
 	go func() {
@@ -69,13 +125,14 @@ func run() error {
 		if err != nil {
 			log.Infof("HTTP Server Shutdown Error: %v", err)
 		}
+		stopGrpcServer(ctx, grpcServer)
 
 		close(stopped)
 	}()
 
 	log.Infof("Starting HTTP server on %s", cfg.Port)
 
-	err := srv.ListenAndServe()
+	err = srv.ListenAndServe()
 	if err != http.ErrServerClosed {
 		log.Fatalf("HTTP server ListenAndServe Error: %v", err)
 	}
@@ -85,3 +142,86 @@ func run() error {
 	log.Info("Server has been stopped")
 	return nil
 }
+
+// newRouter registers the routes of the HTTP API. Every route has to be
+// described in the OpenAPI document, see internal/transport/openapi.
+func newRouter(httpServer transport.HttpServer, webhookServer transport.WebhookServer, graphqlHandler http.Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode("health OK")
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/port", httpServer.GetPort).Methods(http.MethodGet)
+	router.HandleFunc("/count", httpServer.CountPorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports", httpServer.ListPorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports/by-unloc/{unloc}", httpServer.GetPortByUnloc).Methods(http.MethodGet)
+	router.HandleFunc("/ports/by-code/{code}", httpServer.FindPortsByCode).Methods(http.MethodGet)
+	router.HandleFunc("/ports/by-alias/{alias}", httpServer.FindPortsByAlias).Methods(http.MethodGet)
+	router.HandleFunc("/ports/nearest", httpServer.NearestPorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports/within", httpServer.PortsWithin).Methods(http.MethodGet)
+	router.HandleFunc("/ports/search", httpServer.SearchPorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports/autocomplete", httpServer.AutocompletePorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports/export", httpServer.ExportPorts).Methods(http.MethodGet)
+	router.HandleFunc("/ports/changes", httpServer.StreamPortChanges).Methods(http.MethodGet)
+	router.HandleFunc("/ports", httpServer.UploadPorts).Methods(http.MethodPost)
+	router.HandleFunc("/ports/jobs", httpServer.CreateUploadJob).Methods(http.MethodPost)
+	router.HandleFunc("/ports/jobs/{id}", httpServer.GetUploadJob).Methods(http.MethodGet)
+	router.HandleFunc("/ports/jobs/{id}", httpServer.CancelUploadJob).Methods(http.MethodDelete)
+	router.HandleFunc("/ports/{id}/history", httpServer.GetPortHistory).Methods(http.MethodGet)
+	router.HandleFunc("/ports/{id}/restore", httpServer.RestorePort).Methods(http.MethodPost)
+	router.HandleFunc("/ports/{id}", httpServer.GetPortById).Methods(http.MethodGet)
+	router.HandleFunc("/ports/{id}", httpServer.ReplacePort).Methods(http.MethodPut)
+	router.HandleFunc("/ports/{id}", httpServer.PatchPort).Methods(http.MethodPatch)
+	router.HandleFunc("/ports/{id}", httpServer.DeletePortsById).Methods(http.MethodDelete)
+	router.HandleFunc("/ports", httpServer.DeleteAllPorts).Methods(http.MethodDelete)
+	router.HandleFunc("/webhooks", webhookServer.CreateWebhook).Methods(http.MethodPost)
+	router.HandleFunc("/webhooks", webhookServer.ListWebhooks).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/dead-letters", webhookServer.ListDeadLetters).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}", webhookServer.GetWebhook).Methods(http.MethodGet)
+	router.HandleFunc("/webhooks/{id}", webhookServer.ReplaceWebhook).Methods(http.MethodPut)
+	router.HandleFunc("/webhooks/{id}", webhookServer.DeleteWebhook).Methods(http.MethodDelete)
+	router.Handle("/graphql", graphqlHandler).Methods(http.MethodPost)
+	router.HandleFunc("/openapi.json", openapi.ServeSpec).Methods(http.MethodGet)
+	docs := openapi.Docs()
+	router.Handle("/docs", docs).Methods(http.MethodGet)
+	router.Handle("/docs/{asset}", docs).Methods(http.MethodGet)
+
+	return router
+}
+
+// stopGrpcServer stops the gRPC server once its calls are over, or right
+// away when ctx is done first.
+func stopGrpcServer(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Info("gRPC server did not stop in time, closing its connections")
+		srv.Stop()
+	}
+}
+
+// newPortRepository creates the port repository selected in config together
+// with a function releasing its resources.
+func newPortRepository(cfg *config.Config) (services.PortRepository, func() error, error) {
+	switch cfg.StorageDriver {
+	case config.StorageDriverInmem:
+		store := inmem.NewPortStore()
+		store.LimitHistory(cfg.HistoryRevisions)
+		return store, func() error { return nil }, nil
+	case config.StorageDriverFile:
+		store, err := filestore.NewPortStore(cfg.StorageDir, cfg.SnapshotInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open file port store: %w", err)
+		}
+		store.LimitHistory(cfg.HistoryRevisions)
+		return store, store.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
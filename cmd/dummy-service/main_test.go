@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+	"github.com/zhenisduissekov/another-dummy-service/internal/services"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/openapi"
+)
+
+// TestRoutesAreDocumented fails when a route is registered without being
+// described in the OpenAPI document, or described without being registered.
+func TestRoutesAreDocumented(t *testing.T) {
+	t.Parallel()
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openapi.Spec(), &spec))
+	require.True(t, strings.HasPrefix(spec.OpenAPI, "3.1."), "OpenAPI version %s", spec.OpenAPI)
+
+	documented := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			switch method {
+			case "parameters", "summary", "description":
+				continue
+			}
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := make(map[string]bool)
+	err := newTestRouter().Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		require.NoError(t, err, "route %s has no methods", path)
+
+		for _, method := range methods {
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	for route := range registered {
+		require.True(t, documented[route], "route %s is missing from openapi.json", route)
+	}
+	for route := range documented {
+		require.True(t, registered[route], "openapi.json describes %s, which is not registered", route)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	t.Parallel()
+
+	router := newTestRouter()
+	for path, contentType := range map[string]string{
+		"/openapi.json":              "application/json",
+		"/docs":                      "text/html",
+		"/docs/initializer.js":       "text/javascript",
+		"/docs/swagger-ui.css":       "text/css",
+		"/docs/swagger-ui-bundle.js": "text/javascript",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+		require.Contains(t, rec.Header().Get("Content-Type"), contentType, path)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/missing.js", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func newTestRouter() *mux.Router {
+	portService := services.NewPortService(inmem.NewPortStore())
+	webhooks := services.NewWebhooks(portService, transport.EncodePortChange, http.DefaultClient, services.RetryPolicy{Attempts: 1})
+
+	return newRouter(
+		transport.NewHttpServer(portService, domain.ValidationLenient),
+		transport.NewWebhookServer(webhooks),
+		http.NotFoundHandler(),
+	)
+}
@@ -9,6 +9,9 @@ var (
 	ErrorTypeAuthorization  = ErrorType{"authorization"}
 	ErrorTypeIncorrectInput = ErrorType{"incorrect-input"}
 	ErrorTypeNotFound       = ErrorType{"not-found"}
+	// ErrorTypePreconditionFailed is a conditional request whose condition
+	// does not hold, such as an If-Match header naming an outdated version.
+	ErrorTypePreconditionFailed = ErrorType{"precondition-failed"}
 )
 
 type SlugError struct {
@@ -60,3 +63,11 @@ func NewNotFoundError(error, slug string) SlugError {
 		errorType: ErrorTypeNotFound,
 	}
 }
+
+func NewPreconditionFailedError(error, slug string) SlugError {
+	return SlugError{
+		error:     error,
+		slug:      slug,
+		errorType: ErrorTypePreconditionFailed,
+	}
+}
@@ -25,6 +25,41 @@ func NotFound(slug string, err error, w http.ResponseWriter, r *http.Request) {
 	httpRespondWithError(err, slug, w, r, "Not found", http.StatusNotFound)
 }
 
+// UnprocessableEntity reports a request that was understood but could not be
+// fully applied, with details on what went wrong.
+func UnprocessableEntity(slug string, err error, details any, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithErrorDetails(err, slug, details, w, r, "Unprocessable entity", http.StatusUnprocessableEntity)
+}
+
+// BadRequestWithDetails is BadRequest with details on what went wrong.
+func BadRequestWithDetails(slug string, err error, details any, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithErrorDetails(err, slug, details, w, r, "Bad request", http.StatusBadRequest)
+}
+
+func NotAcceptable(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Not acceptable", http.StatusNotAcceptable)
+}
+
+func UnsupportedMediaType(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Unsupported media type", http.StatusUnsupportedMediaType)
+}
+
+func Conflict(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Conflict", http.StatusConflict)
+}
+
+func PreconditionFailed(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Precondition failed", http.StatusPreconditionFailed)
+}
+
+func TooManyRequests(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Too many requests", http.StatusTooManyRequests)
+}
+
+func ServiceUnavailable(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Service unavailable", http.StatusServiceUnavailable)
+}
+
 func RespondWithError(err error, w http.ResponseWriter, r *http.Request) {
 	slugError, ok := err.(errors.SlugError)
 	if !ok {
@@ -39,19 +74,27 @@ func RespondWithError(err error, w http.ResponseWriter, r *http.Request) {
 		BadRequest(slugError.Slug(), slugError, w, r)
 	case errors.ErrorTypeNotFound:
 		NotFound(slugError.Slug(), slugError, w, r)
+	case errors.ErrorTypePreconditionFailed:
+		PreconditionFailed(slugError.Slug(), slugError, w, r)
 	default:
 		InternalError(slugError.Slug(), slugError, w, r)
 	}
 }
 
-func httpRespondWithError(err error, slug string, w http.ResponseWriter, _ *http.Request, msg string, status int) {
+func httpRespondWithError(err error, slug string, w http.ResponseWriter, r *http.Request, msg string, status int) {
+	httpRespondWithErrorDetails(err, slug, nil, w, r, msg, status)
+}
+
+// httpRespondWithErrorDetails logs err and answers with the slug and the
+// details, never with err itself.
+func httpRespondWithErrorDetails(err error, slug string, details any, w http.ResponseWriter, _ *http.Request, msg string, status int) {
 	log.Errorf("error: %s, slug: %s, msg: %s", err, slug, msg)
 
 	resp := ErrorResponse{
 		Slug:       slug,
 		Message:    msg,
 		HTTPStatus: status,
-		Details:    nil,                                   // not in prod err.Error(),                           // Add relevant details if applicable
+		Details:    details,
 		Timestamp:  time.Now().UTC().Format(time.RFC3339), // ISO 8601 format
 	}
 
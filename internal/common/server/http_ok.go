@@ -14,14 +14,28 @@ type ResponseOK struct {
 }
 
 func RespondOK(data any, w http.ResponseWriter, r *http.Request) {
+	httpRespondOK(data, w, r, "Request processed successfully.", http.StatusOK)
+}
+
+// RespondCreated tells the client that the request created a resource.
+func RespondCreated(data any, w http.ResponseWriter, r *http.Request) {
+	httpRespondOK(data, w, r, "Resource created successfully.", http.StatusCreated)
+}
+
+// RespondAccepted tells the client that the request will be processed in the background.
+func RespondAccepted(data any, w http.ResponseWriter, r *http.Request) {
+	httpRespondOK(data, w, r, "Request accepted for processing.", http.StatusAccepted)
+}
+
+func httpRespondOK(data any, w http.ResponseWriter, _ *http.Request, msg string, status int) {
 	resp := ResponseOK{
-		Message:    "Request processed successfully.",
-		HTTPStatus: http.StatusOK,
+		Message:    msg,
+		HTTPStatus: status,
 		Data:       data,
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -1,9 +1,56 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StorageDriverInmem = "inmem"
+	StorageDriverFile  = "file"
+)
 
 type Config struct {
 	Port string
+	// GrpcPort is the address the gRPC API listens on, next to the HTTP one.
+	GrpcPort string
+
+	// StorageDriver selects the port repository: "inmem" or "file".
+	StorageDriver string
+	// StorageDir is the directory the file repository keeps its log and snapshot in.
+	StorageDir string
+	// SnapshotInterval is how often the file repository compacts its log into a snapshot.
+	SnapshotInterval time.Duration
+
+	// PurgeRetention is how long deleted ports are kept as tombstones, and
+	// can be restored, before they are purged for good.
+	PurgeRetention time.Duration
+	// PurgeInterval is how often tombstones past the retention are purged.
+	PurgeInterval time.Duration
+	// HistoryRevisions is how many revisions of a port its history keeps,
+	// each a full copy of the port. Older ones are dropped, and are no
+	// longer listed nor found as of their time.
+	HistoryRevisions int
+
+	// ValidationMode is "lenient" to accept ports that do not match the
+	// country, UN/LOCODE and timezone tables with warnings, or "strict" to
+	// reject them.
+	ValidationMode string
+
+	// WebhookAttempts is how many times a webhook delivery is attempted
+	// before it becomes a dead letter.
+	WebhookAttempts int
+	// WebhookBackoff is the wait before the first retry of a delivery. It
+	// doubles with every retry, up to WebhookMaxBackoff.
+	WebhookBackoff    time.Duration
+	WebhookMaxBackoff time.Duration
+	// WebhookTimeout is how long a webhook has to answer a delivery.
+	WebhookTimeout time.Duration
+	// WebhookAllowedNetworks are the networks, in CIDR notation, webhooks
+	// may be posted to even though they are loopback, link-local or private.
+	WebhookAllowedNetworks []string
 }
 
 func Read() *Config {
@@ -13,7 +60,84 @@ func Read() *Config {
 		port = "8080"
 	}
 
+	grpcPort, exists := os.LookupEnv("GRPC_PORT")
+	if !exists {
+		grpcPort = ":9090"
+	}
+
+	storageDriver, exists := os.LookupEnv("STORAGE_DRIVER")
+	if !exists {
+		storageDriver = StorageDriverInmem
+	}
+
+	storageDir, exists := os.LookupEnv("STORAGE_DIR")
+	if !exists {
+		storageDir = "data"
+	}
+
+	validationMode, exists := os.LookupEnv("VALIDATION_MODE")
+	if !exists {
+		validationMode = "lenient"
+	}
+
 	return &Config{
-		Port: port,
+		Port:                   port,
+		GrpcPort:               grpcPort,
+		StorageDriver:          storageDriver,
+		StorageDir:             storageDir,
+		SnapshotInterval:       readDuration("SNAPSHOT_INTERVAL", time.Minute),
+		PurgeRetention:         readDuration("PURGE_RETENTION", 30*24*time.Hour),
+		PurgeInterval:          readDuration("PURGE_INTERVAL", time.Hour),
+		HistoryRevisions:       readInt("HISTORY_REVISIONS", 100),
+		ValidationMode:         validationMode,
+		WebhookAttempts:        readInt("WEBHOOK_ATTEMPTS", 5),
+		WebhookBackoff:         readDuration("WEBHOOK_BACKOFF", time.Second),
+		WebhookMaxBackoff:      readDuration("WEBHOOK_MAX_BACKOFF", 5*time.Minute),
+		WebhookTimeout:         readDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookAllowedNetworks: readList("WEBHOOK_ALLOWED_NETWORKS"),
 	}
 }
+
+// readDuration reads a duration such as "30s" from env, falling back to def
+// when the variable is missing or malformed.
+func readDuration(key string, def time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+// readInt reads a positive number from env, falling back to def when the
+// variable is missing or malformed.
+func readInt(key string, def int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return def
+	}
+
+	return n
+}
+
+// readList reads a comma separated list from env, leaving out empty items.
+func readList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,77 @@
+package domain
+
+type BatchOpKind int
+
+const (
+	BatchUpsert BatchOpKind = iota
+	BatchDelete
+	// BatchInsert creates a port that must not exist yet.
+	BatchInsert
+	// BatchUpdate updates a port that must exist already.
+	BatchUpdate
+)
+
+// BatchOp is a single staged write of a PortBatch.
+type BatchOp struct {
+	Kind BatchOpKind
+	// Port is set for every kind but BatchDelete.
+	Port *Port
+	// Id is the id of the port the operation applies to.
+	Id string
+}
+
+// PortBatch is a set of writes staged to be applied all together or not at all.
+type PortBatch struct {
+	ops []BatchOp
+}
+
+func NewPortBatch() *PortBatch {
+	return &PortBatch{}
+}
+
+// Upsert stages creating or updating the port.
+func (b *PortBatch) Upsert(port *Port) error {
+	if port == nil {
+		return ErrNil
+	}
+
+	b.ops = append(b.ops, BatchOp{Kind: BatchUpsert, Port: port, Id: port.Id()})
+	return nil
+}
+
+// Insert stages creating the port. The batch fails with ErrAlreadyExists
+// when the port exists by the time it is applied.
+func (b *PortBatch) Insert(port *Port) error {
+	if port == nil {
+		return ErrNil
+	}
+
+	b.ops = append(b.ops, BatchOp{Kind: BatchInsert, Port: port, Id: port.Id()})
+	return nil
+}
+
+// Update stages updating the port. The batch fails with ErrNotFound when
+// the port does not exist by the time it is applied.
+func (b *PortBatch) Update(port *Port) error {
+	if port == nil {
+		return ErrNil
+	}
+
+	b.ops = append(b.ops, BatchOp{Kind: BatchUpdate, Port: port, Id: port.Id()})
+	return nil
+}
+
+// Delete stages deleting the port with the given id.
+func (b *PortBatch) Delete(id string) {
+	b.ops = append(b.ops, BatchOp{Kind: BatchDelete, Id: id})
+}
+
+// Ops returns the staged writes in the order they were staged.
+func (b *PortBatch) Ops() []BatchOp {
+	return b.ops
+}
+
+// Len returns the number of staged writes.
+func (b *PortBatch) Len() int {
+	return len(b.ops)
+}
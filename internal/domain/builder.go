@@ -0,0 +1,145 @@
+package domain
+
+import "errors"
+
+// PortBuilder collects the fields of a port and validates them all at once
+// when the port is built.
+type PortBuilder struct {
+	port       Port
+	coords     []float64
+	validation ValidationMode
+}
+
+func NewPortBuilder() *PortBuilder {
+	return &PortBuilder{}
+}
+
+// Id sets the port id, which is required.
+func (b *PortBuilder) Id(id string) *PortBuilder {
+	b.port.id = id
+	return b
+}
+
+// Name sets the port name, which is required.
+func (b *PortBuilder) Name(name string) *PortBuilder {
+	b.port.name = name
+	return b
+}
+
+// Code sets the port code.
+func (b *PortBuilder) Code(code string) *PortBuilder {
+	b.port.code = code
+	return b
+}
+
+// City sets the port city, which is required.
+func (b *PortBuilder) City(city string) *PortBuilder {
+	b.port.city = city
+	return b
+}
+
+// Country sets the port country, which is required.
+func (b *PortBuilder) Country(country string) *PortBuilder {
+	b.port.country = country
+	return b
+}
+
+// Alias sets the port alias.
+func (b *PortBuilder) Alias(alias ...string) *PortBuilder {
+	b.port.alias = alias
+	return b
+}
+
+// Regions sets the port regions.
+func (b *PortBuilder) Regions(regions ...string) *PortBuilder {
+	b.port.regions = regions
+	return b
+}
+
+// Coordinates sets the port coordinates as [longitude, latitude].
+func (b *PortBuilder) Coordinates(lonLat []float64) *PortBuilder {
+	b.coords = lonLat
+	return b
+}
+
+// Province sets the port province.
+func (b *PortBuilder) Province(province string) *PortBuilder {
+	b.port.province = province
+	return b
+}
+
+// Timezone sets the port timezone.
+func (b *PortBuilder) Timezone(tz string) *PortBuilder {
+	b.port.timezone = tz
+	return b
+}
+
+// Unlocs sets the port unlocs.
+func (b *PortBuilder) Unlocs(unlocs ...string) *PortBuilder {
+	b.port.unlocs = unlocs
+	return b
+}
+
+// Validation sets the mode the port is checked against the reference
+// tables in. Ports are built in ValidationLenient mode by default.
+func (b *PortBuilder) Validation(mode ValidationMode) *PortBuilder {
+	b.validation = mode
+	return b
+}
+
+// Build returns the port, or an error joining a *FieldError for every
+// field that failed validation.
+func (b *PortBuilder) Build() (*Port, error) {
+	var problems []error
+
+	if b.port.id == "" {
+		problems = append(problems, newFieldError("id", ErrRequired, "port id is required"))
+	}
+	if b.port.name == "" {
+		problems = append(problems, newFieldError("name", ErrRequired, "port name is required"))
+	}
+	if b.port.city == "" {
+		problems = append(problems, newFieldError("city", ErrRequired, "port city is required"))
+	}
+	if b.port.country == "" {
+		problems = append(problems, newFieldError("country", ErrRequired, "port country is required"))
+	}
+
+	location, err := CoordinatesFromLonLat(b.coords)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	port := b.port
+	port.location = location
+	if port.country != "" {
+		port.warnings = port.checkReferences()
+	}
+	if err := b.validation.Check(&port); err != nil {
+		problems = append(problems, err)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+
+	return &port, nil
+}
+
+// FieldErrors returns every *FieldError err is made of.
+func FieldErrors(err error) []*FieldError {
+	switch e := err.(type) {
+	case *FieldError:
+		return []*FieldError{e}
+	case interface{ Unwrap() []error }:
+		var fieldErrs []*FieldError
+		for _, inner := range e.Unwrap() {
+			fieldErrs = append(fieldErrs, FieldErrors(inner)...)
+		}
+		return fieldErrs
+	case interface{ Unwrap() error }:
+		return FieldErrors(e.Unwrap())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortBuilder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		port, err := NewPortBuilder().
+			Id("AEAJM").
+			Name("Ajman").
+			City("Ajman").
+			Country("United Arab Emirates").
+			Alias("Ajman Port").
+			Coordinates([]float64{55.5136433, 25.4052165}).
+			Timezone("Asia/Dubai").
+			Unlocs("AEAJM").
+			Build()
+		require.NoError(t, err)
+
+		expected, err := NewPort("AEAJM", "Ajman", "", "Ajman", "United Arab Emirates", []string{"Ajman Port"}, nil, []float64{55.5136433, 25.4052165}, "", "Asia/Dubai", []string{"AEAJM"})
+		require.NoError(t, err)
+		require.True(t, expected.Equal(port))
+	})
+
+	t.Run("every failing field", func(t *testing.T) {
+		_, err := NewPortBuilder().
+			Id("AEAJM").
+			Coordinates([]float64{55.5}).
+			Build()
+		require.ErrorIs(t, err, ErrRequired)
+		require.ErrorIs(t, err, ErrInvalidCoordinates)
+
+		var fields []string
+		for _, fieldErr := range FieldErrors(err) {
+			fields = append(fields, fieldErr.Field)
+		}
+		require.Equal(t, []string{"name", "city", "country", "coordinates"}, fields)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		builder := NewPortBuilder().
+			Id("AEAJM").
+			Name("Ajman").
+			City("Ajman").
+			Country("United Arab Emirates").
+			Timezone("Asia/Dubia")
+
+		port, err := builder.Build()
+		require.NoError(t, err)
+		require.Len(t, port.Warnings(), 1)
+
+		_, err = builder.Validation(ValidationStrict).Name("").Build()
+		require.ErrorIs(t, err, ErrRequired)
+		require.ErrorIs(t, err, ErrUnknownTimezone)
+		require.Len(t, FieldErrors(err), 2)
+	})
+}
+
+func TestFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	fieldErr := newFieldError("name", ErrRequired, "port name is required")
+
+	require.Equal(t, []*FieldError{fieldErr}, FieldErrors(fieldErr))
+	require.Equal(t, []*FieldError{fieldErr}, FieldErrors(fmt.Errorf("port AEAJM: %w", fieldErr)))
+	require.Empty(t, FieldErrors(ErrNotFound))
+	require.Empty(t, FieldErrors(nil))
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// ChangeKind tells what a write did to a port.
+type ChangeKind string
+
+const (
+	ChangeCreated ChangeKind = "created"
+	ChangeUpdated ChangeKind = "updated"
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// PortChange is a write the repository made to a port. Port is the port as
+// the write left it or, for a deletion, as it was before it.
+type PortChange struct {
+	// Sequence numbers the changes in the order they were made. It is given
+	// by the change feed publishing them, repositories leave it zero.
+	Sequence uint64
+	Kind     ChangeKind
+	Id       string
+	Version  uint64
+	At       time.Time
+	Port     *Port
+}
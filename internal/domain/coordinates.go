@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// Coordinates is the location of a port. The zero value is a missing
+// location, for ports whose feed does not give one.
+type Coordinates struct {
+	lat     float64
+	lon     float64
+	present bool
+}
+
+// NewCoordinates returns the location at lat and lon, both in degrees. It
+// fails with ErrInvalidCoordinates when either is out of range or NaN.
+func NewCoordinates(lat, lon float64) (Coordinates, error) {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return Coordinates{}, newCoordinatesError(fmt.Sprintf("latitude %v is out of range", lat))
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return Coordinates{}, newCoordinatesError(fmt.Sprintf("longitude %v is out of range", lon))
+	}
+
+	return Coordinates{lat: lat, lon: lon, present: true}, nil
+}
+
+// CoordinatesFromLonLat reads a location stored as [longitude, latitude],
+// the order of the port feeds and of GeoJSON. An empty array is a missing
+// location.
+func CoordinatesFromLonLat(lonLat []float64) (Coordinates, error) {
+	switch len(lonLat) {
+	case 0:
+		return Coordinates{}, nil
+	case 2:
+		return NewCoordinates(lonLat[1], lonLat[0])
+	default:
+		return Coordinates{}, newCoordinatesError(fmt.Sprintf("expected [longitude, latitude], got %d values", len(lonLat)))
+	}
+}
+
+func newCoordinatesError(msg string) *FieldError {
+	return newFieldError("coordinates", ErrInvalidCoordinates, msg)
+}
+
+// Lat returns the latitude in degrees.
+func (c Coordinates) Lat() float64 {
+	return c.lat
+}
+
+// Lon returns the longitude in degrees.
+func (c Coordinates) Lon() float64 {
+	return c.lon
+}
+
+// IsMissing reports whether the location is unknown.
+func (c Coordinates) IsMissing() bool {
+	return !c.present
+}
+
+// LonLat returns the location as [longitude, latitude], nil when it is missing.
+func (c Coordinates) LonLat() []float64 {
+	if !c.present {
+		return nil
+	}
+	return []float64{c.lon, c.lat}
+}
+
+// DistanceKm returns the great-circle distance to other in kilometres.
+func (c Coordinates) DistanceKm(other Coordinates) float64 {
+	return DistanceKm(c.lat, c.lon, other.lat, other.lon)
+}
+
+// BearingTo returns the initial bearing of the great circle to other, in
+// degrees clockwise from north within [0, 360).
+func (c Coordinates) BearingTo(other Coordinates) float64 {
+	phi1 := c.lat * math.Pi / 180
+	phi2 := other.lat * math.Pi / 180
+	dLambda := (other.lon - c.lon) * math.Pi / 180
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func (c Coordinates) String() string {
+	if !c.present {
+		return "missing"
+	}
+	return fmt.Sprintf("%v,%v", c.lat, c.lon)
+}
@@ -1,9 +1,58 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	ErrRequired = errors.New("required value")
-	ErrNotFound = errors.New("not found")
-	ErrNil      = errors.New("nil data")
+	ErrRequired      = errors.New("required value")
+	ErrNotFound      = errors.New("not found")
+	ErrNil           = errors.New("nil data")
+	ErrInvalidQuery  = errors.New("invalid query")
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrVersionMismatch is returned by a conditional write when the stored
+	// port is no longer at the version the caller expects.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrInvalidCoordinates is returned for coordinates that are not a
+	// latitude and longitude within range.
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+	// ErrInvalidUnloc, ErrUnknownCountry and ErrUnknownTimezone are the
+	// problems found by checking a port against the reference tables.
+	ErrInvalidUnloc    = errors.New("invalid unloc")
+	ErrUnknownCountry  = errors.New("unknown country")
+	ErrUnknownTimezone = errors.New("unknown timezone")
+	// ErrChangesExpired is returned when resuming the change feed after a
+	// change it no longer keeps, so that some changes would be missed.
+	ErrChangesExpired = errors.New("changes expired")
+	// ErrChangesClosed is returned when watching the change feed once it
+	// is closed.
+	ErrChangesClosed = errors.New("change feed closed")
+	// ErrInvalidWebhook is returned for webhooks with a malformed URL or
+	// filter.
+	ErrInvalidWebhook = errors.New("invalid webhook")
 )
+
+// FieldError tells which field of a port failed validation. It unwraps to
+// the domain error describing the failure, such as ErrRequired.
+type FieldError struct {
+	Field string
+	Err   error
+	Msg   string
+}
+
+func newFieldError(field string, err error, msg string) *FieldError {
+	return &FieldError{
+		Field: field,
+		Err:   err,
+		Msg:   msg,
+	}
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.Msg)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,76 @@
+package domain
+
+// Event is something that happened to a port. Ports record the events of
+// their mutation methods until the service layer dispatches them, once the
+// changed port is stored.
+type Event interface {
+	// EventName names the kind of event, such as "port.renamed".
+	EventName() string
+	// PortId is the id of the port the event happened to.
+	PortId() string
+}
+
+// PortRenamed is recorded by Port.Rename.
+type PortRenamed struct {
+	Id      string
+	OldName string
+	NewName string
+}
+
+func (e PortRenamed) EventName() string { return "port.renamed" }
+func (e PortRenamed) PortId() string    { return e.Id }
+
+// PortRelocated is recorded by Port.Relocate.
+type PortRelocated struct {
+	Id          string
+	OldLocation Coordinates
+	NewLocation Coordinates
+}
+
+func (e PortRelocated) EventName() string { return "port.relocated" }
+func (e PortRelocated) PortId() string    { return e.Id }
+
+// AliasAdded is recorded by Port.AddAlias.
+type AliasAdded struct {
+	Id    string
+	Alias string
+}
+
+func (e AliasAdded) EventName() string { return "port.alias_added" }
+func (e AliasAdded) PortId() string    { return e.Id }
+
+// AliasRemoved is recorded by Port.RemoveAlias.
+type AliasRemoved struct {
+	Id    string
+	Alias string
+}
+
+func (e AliasRemoved) EventName() string { return "port.alias_removed" }
+func (e AliasRemoved) PortId() string    { return e.Id }
+
+// RegionAssigned is recorded by Port.AssignRegion.
+type RegionAssigned struct {
+	Id     string
+	Region string
+}
+
+func (e RegionAssigned) EventName() string { return "port.region_assigned" }
+func (e RegionAssigned) PortId() string    { return e.Id }
+
+// UnlocAdded is recorded by Port.AddUnloc.
+type UnlocAdded struct {
+	Id    string
+	Unloc string
+}
+
+func (e UnlocAdded) EventName() string { return "port.unloc_added" }
+func (e UnlocAdded) PortId() string    { return e.Id }
+
+// UnlocRemoved is recorded by Port.RemoveUnloc.
+type UnlocRemoved struct {
+	Id    string
+	Unloc string
+}
+
+func (e UnlocRemoved) EventName() string { return "port.unloc_removed" }
+func (e UnlocRemoved) PortId() string    { return e.Id }
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadiusKm is the mean radius of the Earth.
+const EarthRadiusKm = 6371.0088
+
+// MaxNearest caps the number of ports a nearest-port query returns.
+const MaxNearest = 100
+
+// DistanceKm returns the great-circle distance between two points in kilometres.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// ValidateLatLon checks that lat and lon are within their ranges.
+func ValidateLatLon(lat, lon float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("%w: latitude %v is out of range", ErrInvalidQuery, lat)
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return fmt.Errorf("%w: longitude %v is out of range", ErrInvalidQuery, lon)
+	}
+	return nil
+}
+
+// BoundingBox is an area between two latitudes and two longitudes. A box
+// with MinLon greater than MaxLon crosses the antimeridian.
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Validate checks that the box corners are valid coordinates.
+func (b BoundingBox) Validate() error {
+	if err := ValidateLatLon(b.MinLat, b.MinLon); err != nil {
+		return err
+	}
+	if err := ValidateLatLon(b.MaxLat, b.MaxLon); err != nil {
+		return err
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("%w: minimum latitude is greater than maximum latitude", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// Contains reports whether the point lies within the box.
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// Center returns the middle point of the box.
+func (b BoundingBox) Center() (lat, lon float64) {
+	span := b.MaxLon - b.MinLon
+	if span < 0 {
+		span += 360
+	}
+
+	lon = b.MinLon + span/2
+	if lon > 180 {
+		lon -= 360
+	}
+
+	return (b.MinLat + b.MaxLat) / 2, lon
+}
+
+// NearbyPort is a port found by a geospatial query together with its
+// distance from the point of the query.
+type NearbyPort struct {
+	Port *Port
+	// Record is the record of Port, for callers that need its version or
+	// timestamps.
+	Record     *PortRecord
+	DistanceKm float64
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistanceKm(t *testing.T) {
+	t.Parallel()
+
+	// London to Paris
+	require.InDelta(t, 343.5, DistanceKm(51.5074, -0.1278, 48.8566, 2.3522), 1)
+	// across the antimeridian
+	require.InDelta(t, 222.4, DistanceKm(0, 179, 0, -179), 0.5)
+	require.Zero(t, DistanceKm(10, 10, 10, 10))
+}
+
+func TestBoundingBox(t *testing.T) {
+	t.Parallel()
+
+	box := BoundingBox{MinLat: -20, MinLon: 170, MaxLat: -10, MaxLon: -170}
+	require.NoError(t, box.Validate())
+	require.True(t, box.Contains(-15, 175))
+	require.True(t, box.Contains(-15, -175))
+	require.False(t, box.Contains(-15, 0))
+
+	lat, lon := box.Center()
+	require.InDelta(t, -15, lat, 1e-9)
+	require.InDelta(t, 180, lon, 1e-9)
+
+	require.ErrorIs(t, BoundingBox{MinLat: 10, MaxLat: 0}.Validate(), ErrInvalidQuery)
+}
+
+func TestCoordinates(t *testing.T) {
+	t.Parallel()
+
+	london, err := NewCoordinates(51.5074, -0.1278)
+	require.NoError(t, err)
+	paris, err := NewCoordinates(48.8566, 2.3522)
+	require.NoError(t, err)
+
+	require.InDelta(t, 343.5, london.DistanceKm(paris), 1)
+	require.InDelta(t, 148.1, london.BearingTo(paris), 0.5)
+	require.InDelta(t, 329.9, paris.BearingTo(london), 0.5)
+
+	equator, err := NewCoordinates(0, 0)
+	require.NoError(t, err)
+	north, err := NewCoordinates(10, 0)
+	require.NoError(t, err)
+	west, err := NewCoordinates(0, -10)
+	require.NoError(t, err)
+	require.InDelta(t, 0, equator.BearingTo(north), 1e-9)
+	require.InDelta(t, 270, equator.BearingTo(west), 1e-9)
+
+	require.False(t, equator.IsMissing())
+	require.Equal(t, []float64{0, 0}, equator.LonLat())
+	require.True(t, Coordinates{}.IsMissing())
+	require.Nil(t, Coordinates{}.LonLat())
+
+	_, err = NewCoordinates(-91, 0)
+	require.ErrorIs(t, err, ErrInvalidCoordinates)
+	_, err = NewCoordinates(0, math.Inf(1))
+	require.ErrorIs(t, err, ErrInvalidCoordinates)
+}
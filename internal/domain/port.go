@@ -1,48 +1,48 @@
 package domain
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
 
 type Port struct {
-	id          string
-	name        string
-	code        string
-	city        string
-	country     string
-	alias       []string
-	regions     []string
-	coordinates []float64
-	province    string
-	timezone    string
-	unlocs      []string
+	id       string
+	name     string
+	code     string
+	city     string
+	country  string
+	alias    []string
+	regions  []string
+	location Coordinates
+	province string
+	timezone string
+	unlocs   []string
+	// deletedAt is set on ports read back from their tombstone.
+	deletedAt time.Time
+	// events are recorded by the mutation methods until they are pulled.
+	events []Event
+	// warnings are the problems found by checking the port against the
+	// reference tables, see ValidationMode.
+	warnings []*FieldError
 }
 
+// NewPort builds a port from all its fields, see PortBuilder.
 func NewPort(id, name, code, city, country string, alias, regions []string, coords []float64, province, tz string, unlocs []string) (*Port, error) {
-	if id == "" {
-		return nil, fmt.Errorf("%w: port id is required", ErrRequired)
-	}
-	if name == "" {
-		return nil, fmt.Errorf("%w: port name is required", ErrRequired)
-	}
-	if city == "" {
-		return nil, fmt.Errorf("%w: port city is required", ErrRequired)
-	}
-	if country == "" {
-		return nil, fmt.Errorf("%w: port country is required", ErrRequired)
-	}
-
-	return &Port{
-		id:          id,
-		name:        name,
-		code:        code,
-		city:        city,
-		country:     country,
-		alias:       alias,
-		regions:     regions,
-		coordinates: coords,
-		province:    province,
-		timezone:    tz,
-		unlocs:      unlocs,
-	}, nil
+	return NewPortBuilder().
+		Id(id).
+		Name(name).
+		Code(code).
+		City(city).
+		Country(country).
+		Alias(alias...).
+		Regions(regions...).
+		Coordinates(coords).
+		Province(province).
+		Timezone(tz).
+		Unlocs(unlocs...).
+		Build()
 }
 
 // Id returns the port id.
@@ -55,10 +55,10 @@ func (p *Port) Name() string {
 	return p.name
 }
 
-// SetName sets the port name.
+// SetName sets the port name without recording an event, see Rename.
 func (p *Port) SetName(name string) error {
 	if name == "" {
-		return fmt.Errorf("%w: port name is required", ErrRequired)
+		return newFieldError("name", ErrRequired, "port name is required")
 	}
 	p.name = name
 	return nil
@@ -89,9 +89,15 @@ func (p *Port) Regions() []string {
 	return p.regions
 }
 
-// Coordinates returns the port coordinates.
+// Coordinates returns the port coordinates as [longitude, latitude], nil
+// when the location is missing.
 func (p *Port) Coordinates() []float64 {
-	return p.coordinates
+	return p.location.LonLat()
+}
+
+// Location returns the port coordinates.
+func (p *Port) Location() Coordinates {
+	return p.location
 }
 
 // Province returns the port province.
@@ -108,3 +114,228 @@ func (p *Port) Timezone() string {
 func (p *Port) Unlocs() []string {
 	return p.unlocs
 }
+
+// Warnings returns the problems found by checking the country, unlocs and
+// timezone of the port against the reference tables.
+func (p *Port) Warnings() []*FieldError {
+	return p.warnings
+}
+
+// DeletedAt returns when the port was deleted, the zero time for a live port.
+func (p *Port) DeletedAt() time.Time {
+	return p.deletedAt
+}
+
+// IsDeleted reports whether the port is a tombstone.
+func (p *Port) IsDeleted() bool {
+	return !p.deletedAt.IsZero()
+}
+
+// SetDeletedAt marks the port as deleted at the given time.
+func (p *Port) SetDeletedAt(at time.Time) {
+	p.deletedAt = at
+}
+
+// Rename gives the port a new name.
+func (p *Port) Rename(name string) error {
+	if name == "" {
+		return newFieldError("name", ErrRequired, "port name is required")
+	}
+	if name == p.name {
+		return nil
+	}
+
+	p.record(PortRenamed{Id: p.id, OldName: p.name, NewName: name})
+	p.name = name
+	return nil
+}
+
+// Relocate moves the port to the coordinates.
+func (p *Port) Relocate(location Coordinates) error {
+	if location.IsMissing() {
+		return newFieldError("coordinates", ErrRequired, "port coordinates are required")
+	}
+	if location == p.location {
+		return nil
+	}
+
+	p.record(PortRelocated{Id: p.id, OldLocation: p.location, NewLocation: location})
+	p.location = location
+	return nil
+}
+
+// AddAlias adds an alias the port is not known by yet.
+func (p *Port) AddAlias(alias string) error {
+	if alias == "" {
+		return newFieldError("alias", ErrRequired, "port alias is required")
+	}
+	if slices.Contains(p.alias, alias) {
+		return newFieldError("alias", ErrAlreadyExists, fmt.Sprintf("port alias %s already exists", alias))
+	}
+
+	// clip, so that appending never writes into a list shared with the caller
+	p.alias = append(slices.Clip(p.alias), alias)
+	p.record(AliasAdded{Id: p.id, Alias: alias})
+	return nil
+}
+
+// RemoveAlias removes an alias of the port.
+func (p *Port) RemoveAlias(alias string) error {
+	i := slices.Index(p.alias, alias)
+	if i < 0 {
+		return newFieldError("alias", ErrNotFound, fmt.Sprintf("port alias %s not found", alias))
+	}
+
+	p.alias = slices.Delete(slices.Clone(p.alias), i, i+1)
+	p.record(AliasRemoved{Id: p.id, Alias: alias})
+	return nil
+}
+
+// AssignRegion adds the port to a region. Assigning a region the port is
+// already in changes nothing.
+func (p *Port) AssignRegion(region string) error {
+	if region == "" {
+		return newFieldError("regions", ErrRequired, "port region is required")
+	}
+	if slices.Contains(p.regions, region) {
+		return nil
+	}
+
+	p.regions = append(slices.Clip(p.regions), region)
+	p.record(RegionAssigned{Id: p.id, Region: region})
+	return nil
+}
+
+// AddUnloc adds a UN/LOCODE the port does not have yet.
+func (p *Port) AddUnloc(unloc string) error {
+	if unloc == "" {
+		return newFieldError("unlocs", ErrRequired, "port unloc is required")
+	}
+	if slices.Contains(p.unlocs, unloc) {
+		return newFieldError("unlocs", ErrAlreadyExists, fmt.Sprintf("port unloc %s already exists", unloc))
+	}
+
+	p.unlocs = append(slices.Clip(p.unlocs), unloc)
+	p.warnings = p.checkReferences()
+	p.record(UnlocAdded{Id: p.id, Unloc: unloc})
+	return nil
+}
+
+// RemoveUnloc removes a UN/LOCODE of the port.
+func (p *Port) RemoveUnloc(unloc string) error {
+	i := slices.Index(p.unlocs, unloc)
+	if i < 0 {
+		return newFieldError("unlocs", ErrNotFound, fmt.Sprintf("port unloc %s not found", unloc))
+	}
+
+	p.unlocs = slices.Delete(slices.Clone(p.unlocs), i, i+1)
+	p.warnings = p.checkReferences()
+	p.record(UnlocRemoved{Id: p.id, Unloc: unloc})
+	return nil
+}
+
+// ChangeTo gives the port the data of target, which has to be the same
+// port, through the mutation methods so that the events of the changes
+// are recorded. Fields without a mutation method, and regions the target
+// is no longer in, are copied as they are. The lists end up in the order
+// of target. On error the port may be left half changed.
+func (p *Port) ChangeTo(target *Port) error {
+	if target == nil {
+		return ErrNil
+	}
+	if target.id != p.id {
+		return fmt.Errorf("port %s cannot be changed into port %s", p.id, target.id)
+	}
+
+	err := p.Rename(target.name)
+	if err != nil {
+		return err
+	}
+
+	if target.location.IsMissing() {
+		p.location = target.location
+	} else {
+		err = p.Relocate(target.location)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, alias := range slices.Clone(p.alias) {
+		if !slices.Contains(target.alias, alias) {
+			err = errors.Join(err, p.RemoveAlias(alias))
+		}
+	}
+	for _, alias := range target.alias {
+		if !slices.Contains(p.alias, alias) {
+			err = errors.Join(err, p.AddAlias(alias))
+		}
+	}
+
+	for _, region := range target.regions {
+		err = errors.Join(err, p.AssignRegion(region))
+	}
+
+	for _, unloc := range slices.Clone(p.unlocs) {
+		if !slices.Contains(target.unlocs, unloc) {
+			err = errors.Join(err, p.RemoveUnloc(unloc))
+		}
+	}
+	for _, unloc := range target.unlocs {
+		if !slices.Contains(p.unlocs, unloc) {
+			err = errors.Join(err, p.AddUnloc(unloc))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	p.code = target.code
+	p.city = target.city
+	p.country = target.country
+	p.province = target.province
+	p.timezone = target.timezone
+	p.alias = slices.Clone(target.alias)
+	p.regions = slices.Clone(target.regions)
+	p.unlocs = slices.Clone(target.unlocs)
+	p.warnings = target.warnings
+	return nil
+}
+
+func (p *Port) record(event Event) {
+	p.events = append(p.events, event)
+}
+
+// Events returns the events recorded since they were last pulled.
+func (p *Port) Events() []Event {
+	return p.events
+}
+
+// PullEvents returns the recorded events and forgets them, so that they are
+// dispatched only once.
+func (p *Port) PullEvents() []Event {
+	events := p.events
+	p.events = nil
+	return events
+}
+
+// Equal reports whether both ports hold the same data. Nil and empty lists
+// are considered equal, the deletion time, the events and the warnings are
+// not compared.
+func (p *Port) Equal(other *Port) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+
+	return p.id == other.id &&
+		p.name == other.name &&
+		p.code == other.code &&
+		p.city == other.city &&
+		p.country == other.country &&
+		slices.Equal(p.alias, other.alias) &&
+		slices.Equal(p.regions, other.regions) &&
+		p.location == other.location &&
+		p.province == other.province &&
+		p.timezone == other.timezone &&
+		slices.Equal(p.unlocs, other.unlocs)
+}
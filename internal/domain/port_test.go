@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -45,3 +46,167 @@ func TestNewPort(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestNewPort_coordinates(t *testing.T) {
+	t.Parallel()
+
+	port, err := NewPort("id", "name", "code", "city", "country", nil, nil, nil, "", "", nil)
+	require.NoError(t, err)
+	require.True(t, port.Location().IsMissing())
+	require.Nil(t, port.Coordinates())
+
+	port, err = NewPort("id", "name", "code", "city", "country", nil, nil, []float64{55.5136433, 25.4052165}, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, 25.4052165, port.Location().Lat())
+	require.Equal(t, 55.5136433, port.Location().Lon())
+
+	for _, coords := range [][]float64{
+		{55.5},
+		{55.5, 25.4, 0},
+		{math.NaN(), 25.4},
+		{55.5, math.NaN()},
+		{25.4, 95},
+		{181, 25.4},
+	} {
+		_, err := NewPort("id", "name", "code", "city", "country", nil, nil, coords, "", "", nil)
+		require.ErrorIs(t, err, ErrInvalidCoordinates, "%v", coords)
+
+		var fieldErr *FieldError
+		require.ErrorAs(t, err, &fieldErr)
+		require.Equal(t, "coordinates", fieldErr.Field)
+	}
+}
+
+func TestNewPort_fieldError(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPort("id", "name", "code", "", "country", nil, nil, nil, "", "", nil)
+	require.ErrorIs(t, err, ErrRequired)
+
+	var fieldErr *FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	require.Equal(t, "city", fieldErr.Field)
+	require.Equal(t, "required value: port city is required", err.Error())
+}
+
+func TestPort_Equal(t *testing.T) {
+	t.Parallel()
+
+	port, err := NewPort("id", "name", "code", "city", "country", nil, []string{}, []float64{1, 2}, "", "", nil)
+	require.NoError(t, err)
+	same, err := NewPort("id", "name", "code", "city", "country", []string{}, nil, []float64{1, 2}, "", "", []string{})
+	require.NoError(t, err)
+	other, err := NewPort("id", "name", "code", "city", "country", nil, nil, []float64{2, 1}, "", "", nil)
+	require.NoError(t, err)
+
+	require.True(t, port.Equal(same))
+	require.False(t, port.Equal(other))
+	require.False(t, port.Equal(nil))
+}
+
+func TestPort_mutations(t *testing.T) {
+	t.Parallel()
+
+	newPort := func(t *testing.T) *Port {
+		port, err := NewPort("AEAJM", "Ajman", "52000", "Ajman", "United Arab Emirates", []string{"Ajman Port"}, []string{"Gulf"}, []float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM"})
+		require.NoError(t, err)
+		return port
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		port := newPort(t)
+
+		require.NoError(t, port.Rename("Ajman Port"))
+		require.NoError(t, port.Rename("Ajman Port"))
+		require.Equal(t, "Ajman Port", port.Name())
+		require.Equal(t, []Event{PortRenamed{Id: "AEAJM", OldName: "Ajman", NewName: "Ajman Port"}}, port.Events())
+
+		require.ErrorIs(t, port.Rename(""), ErrRequired)
+	})
+
+	t.Run("relocate", func(t *testing.T) {
+		port := newPort(t)
+
+		old := port.Location()
+		dubai, err := NewCoordinates(25.2, 55.3)
+		require.NoError(t, err)
+
+		require.NoError(t, port.Relocate(dubai))
+		require.NoError(t, port.Relocate(dubai))
+		require.Equal(t, []float64{55.3, 25.2}, port.Coordinates())
+		require.Equal(t, []Event{PortRelocated{Id: "AEAJM", OldLocation: old, NewLocation: dubai}}, port.Events())
+
+		require.ErrorIs(t, port.Relocate(Coordinates{}), ErrRequired)
+		require.Equal(t, dubai, port.Location())
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		alias := []string{"Ajman Port"}
+		port, err := NewPort("AEAJM", "Ajman", "", "Ajman", "United Arab Emirates", alias, nil, nil, "", "", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, port.AddAlias("Ajman Harbour"))
+		require.ErrorIs(t, port.AddAlias("Ajman Harbour"), ErrAlreadyExists)
+		require.NoError(t, port.RemoveAlias("Ajman Port"))
+		require.ErrorIs(t, port.RemoveAlias("Ajman Port"), ErrNotFound)
+
+		require.Equal(t, []string{"Ajman Harbour"}, port.Alias())
+		require.Equal(t, []string{"Ajman Port"}, alias, "the list given to NewPort must not change")
+		require.Equal(t, []Event{
+			AliasAdded{Id: "AEAJM", Alias: "Ajman Harbour"},
+			AliasRemoved{Id: "AEAJM", Alias: "Ajman Port"},
+		}, port.Events())
+	})
+
+	t.Run("regions", func(t *testing.T) {
+		port := newPort(t)
+
+		require.NoError(t, port.AssignRegion("Middle East"))
+		require.NoError(t, port.AssignRegion("Gulf"))
+		require.Equal(t, []string{"Gulf", "Middle East"}, port.Regions())
+		require.Equal(t, []Event{RegionAssigned{Id: "AEAJM", Region: "Middle East"}}, port.Events())
+	})
+
+	t.Run("unlocs", func(t *testing.T) {
+		port := newPort(t)
+
+		require.ErrorIs(t, port.AddUnloc("AEAJM"), ErrAlreadyExists)
+		require.NoError(t, port.AddUnloc("AEQAJ"))
+		require.NoError(t, port.RemoveUnloc("AEAJM"))
+		require.ErrorIs(t, port.RemoveUnloc("AEAJM"), ErrNotFound)
+		require.Equal(t, []string{"AEQAJ"}, port.Unlocs())
+		require.Len(t, port.Events(), 2)
+	})
+
+	t.Run("change to", func(t *testing.T) {
+		port := newPort(t)
+		old := port.Location()
+		target, err := NewPort("AEAJM", "Ajman Port", "52001", "Ajman", "United Arab Emirates", []string{"Ajman Harbour"}, []string{"Gulf", "Middle East"}, []float64{55.3, 25.2}, "Ajman", "Asia/Dubai", []string{"AEQAJ"})
+		require.NoError(t, err)
+
+		require.NoError(t, port.ChangeTo(target))
+		require.True(t, port.Equal(target))
+		require.Equal(t, []Event{
+			PortRenamed{Id: "AEAJM", OldName: "Ajman", NewName: "Ajman Port"},
+			PortRelocated{Id: "AEAJM", OldLocation: old, NewLocation: target.Location()},
+			AliasRemoved{Id: "AEAJM", Alias: "Ajman Port"},
+			AliasAdded{Id: "AEAJM", Alias: "Ajman Harbour"},
+			RegionAssigned{Id: "AEAJM", Region: "Middle East"},
+			UnlocRemoved{Id: "AEAJM", Unloc: "AEAJM"},
+			UnlocAdded{Id: "AEAJM", Unloc: "AEQAJ"},
+		}, port.Events())
+
+		other, err := NewPort("AEQAJ", "Ajman", "", "Ajman", "United Arab Emirates", nil, nil, nil, "", "", nil)
+		require.NoError(t, err)
+		require.Error(t, port.ChangeTo(other))
+		require.ErrorIs(t, port.ChangeTo(nil), ErrNil)
+	})
+
+	t.Run("pull events", func(t *testing.T) {
+		port := newPort(t)
+		require.NoError(t, port.Rename("Ajman Port"))
+
+		require.Len(t, port.PullEvents(), 1)
+		require.Empty(t, port.PullEvents())
+	})
+}
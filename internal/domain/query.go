@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// PortSort is the field ports are listed by.
+type PortSort string
+
+const (
+	SortById   PortSort = "id"
+	SortByName PortSort = "name"
+)
+
+// PortFilter narrows a port listing down. Empty fields match any port,
+// non-empty ones are compared case-insensitively.
+type PortFilter struct {
+	Country  string
+	City     string
+	Province string
+	Timezone string
+	Region   string
+	Unloc    string
+}
+
+// PortQuery describes a page of a port listing.
+type PortQuery struct {
+	Filter PortFilter
+	Sort   PortSort
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+	Limit  int
+	// IncludeDeleted lists tombstoned ports along with the live ones.
+	IncludeDeleted bool
+}
+
+// PortPage is a single page of a port listing.
+type PortPage struct {
+	Ports []*Port
+	// Records are the records of Ports, in the same order, for callers that
+	// need their versions or timestamps.
+	Records []*PortRecord
+	// NextCursor points at the next page, empty when this is the last one.
+	NextCursor string
+}
+
+// Normalize fills in defaults and validates the query.
+func (q PortQuery) Normalize() (PortQuery, error) {
+	switch q.Sort {
+	case "":
+		q.Sort = SortById
+	case SortById, SortByName:
+	default:
+		return q, fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, q.Sort)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageLimit
+	case q.Limit < 0:
+		return q, fmt.Errorf("%w: limit must be positive", ErrInvalidQuery)
+	case q.Limit > MaxPageLimit:
+		q.Limit = MaxPageLimit
+	}
+
+	return q, nil
+}
+
+// PortCursor is the decoded position of the last port of a page.
+type PortCursor struct {
+	Sort PortSort
+	Key  string
+	Id   string
+}
+
+// EncodeCursor returns an opaque cursor for the given position.
+func EncodeCursor(c PortCursor) string {
+	raw := strings.Join([]string{string(c.Sort), c.Key, c.Id}, "\x00")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor made by EncodeCursor and checks it was made
+// for the same sort order.
+func DecodeCursor(cursor string, sort PortSort) (PortCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return PortCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 {
+		return PortCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	c := PortCursor{
+		Sort: PortSort(parts[0]),
+		Key:  parts[1],
+		Id:   parts[2],
+	}
+	if c.Sort != sort {
+		return PortCursor{}, fmt.Errorf("%w: cursor was made for sort %q", ErrInvalidQuery, c.Sort)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// PortRecord is a port as the repository keeps it. Version grows with every
+// write of the port and is what optimistic concurrency control compares.
+type PortRecord struct {
+	Port      *Port
+	Version   uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PortRevision is one entry of the history of a port: the port as a write
+// left it, or its deletion, in which case Port is nil.
+type PortRevision struct {
+	Version uint64
+	At      time.Time
+	Deleted bool
+	Port    *Port
+}
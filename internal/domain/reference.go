@@ -0,0 +1,136 @@
+package domain
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	// timezones are checked against the embedded database, so that
+	// validation does not depend on the zoneinfo of the host
+	_ "time/tzdata"
+)
+
+//go:embed countries.tsv
+var countriesTSV string
+
+// countryCodes maps lower-cased country names to their ISO 3166-1 alpha-2 code.
+var countryCodes = parseCountries(countriesTSV)
+
+func parseCountries(table string) map[string]string {
+	codes := make(map[string]string)
+	for _, line := range strings.Split(table, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		code, names, _ := strings.Cut(line, "\t")
+		for _, name := range strings.Split(names, "|") {
+			codes[strings.ToLower(name)] = code
+		}
+	}
+
+	return codes
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of the country name.
+func CountryCode(country string) (string, bool) {
+	code, ok := countryCodes[strings.ToLower(strings.TrimSpace(country))]
+	return code, ok
+}
+
+// unlocPattern is the format of a UN/LOCODE: the country code followed by
+// three letters or digits from 2 to 9.
+var unlocPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z2-9]{3}$`)
+
+// timezones caches the timezone names that could be loaded. Names that
+// could not are not cached, so that clients cannot grow it without bound:
+// the database only has so many names.
+var timezones sync.Map
+
+func isTimezone(name string) bool {
+	if _, known := timezones.Load(name); known {
+		return true
+	}
+
+	// LoadLocation also accepts "Local"
+	if _, err := time.LoadLocation(name); err != nil || name == "Local" {
+		return false
+	}
+	timezones.Store(name, struct{}{})
+
+	return true
+}
+
+// checkReferences checks the country, unlocs and timezone of the port
+// against the reference tables.
+func (p *Port) checkReferences() []*FieldError {
+	var problems []*FieldError
+
+	countryCode, knownCountry := CountryCode(p.country)
+	if !knownCountry {
+		problems = append(problems, newFieldError("country", ErrUnknownCountry, fmt.Sprintf("port country %s is not an ISO 3166 country", p.country)))
+	}
+
+	for _, unloc := range p.unlocs {
+		switch {
+		case !unlocPattern.MatchString(unloc):
+			problems = append(problems, newFieldError("unlocs", ErrInvalidUnloc, fmt.Sprintf("port unloc %s is not a UN/LOCODE", unloc)))
+		case knownCountry && unloc[:2] != countryCode:
+			problems = append(problems, newFieldError("unlocs", ErrInvalidUnloc, fmt.Sprintf("port unloc %s is not in %s (%s)", unloc, p.country, countryCode)))
+		}
+	}
+
+	if p.timezone != "" && !isTimezone(p.timezone) {
+		problems = append(problems, newFieldError("timezone", ErrUnknownTimezone, fmt.Sprintf("port timezone %s is not an IANA timezone", p.timezone)))
+	}
+
+	return problems
+}
+
+// ValidationMode decides what becomes of ports whose country, unlocs or
+// timezone do not match the reference tables.
+type ValidationMode int
+
+const (
+	// ValidationLenient accepts such ports, their problems are warnings.
+	ValidationLenient ValidationMode = iota
+	// ValidationStrict rejects them.
+	ValidationStrict
+)
+
+// ParseValidationMode reads "lenient" or "strict". An empty mode is lenient.
+func ParseValidationMode(mode string) (ValidationMode, error) {
+	switch mode {
+	case "", "lenient":
+		return ValidationLenient, nil
+	case "strict":
+		return ValidationStrict, nil
+	default:
+		return ValidationLenient, fmt.Errorf("unknown validation mode %q, expected \"lenient\" or \"strict\"", mode)
+	}
+}
+
+func (m ValidationMode) String() string {
+	if m == ValidationStrict {
+		return "strict"
+	}
+	return "lenient"
+}
+
+// Check returns the warnings of the port, joined, when they reject it in
+// this mode.
+func (m ValidationMode) Check(port *Port) error {
+	if m != ValidationStrict {
+		return nil
+	}
+
+	problems := make([]error, 0, len(port.warnings))
+	for _, warning := range port.warnings {
+		problems = append(problems, warning)
+	}
+
+	return errors.Join(problems...)
+}
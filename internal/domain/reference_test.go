@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountryCode(t *testing.T) {
+	t.Parallel()
+
+	for name, code := range map[string]string{
+		"United Arab Emirates":            "AE",
+		"united arab emirates":            "AE",
+		"South Korea":                     "KR",
+		"Korea, Republic of":              "KR",
+		"Bolivia, Plurinational State of": "BO",
+		"Côte d'Ivoire":                   "CI",
+		"Netherlands Antilles":            "AN",
+	} {
+		actual, ok := CountryCode(name)
+		require.True(t, ok, name)
+		require.Equal(t, code, actual, name)
+	}
+
+	_, ok := CountryCode("Untied Arab Emirates")
+	require.False(t, ok)
+}
+
+func TestNewPort_warnings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("clean", func(t *testing.T) {
+		port, err := NewPort("AEAJM", "Ajman", "", "Ajman", "United Arab Emirates", nil, nil, nil, "", "Asia/Dubai", []string{"AEAJM"})
+		require.NoError(t, err)
+		require.Empty(t, port.Warnings())
+		require.NoError(t, ValidationStrict.Check(port))
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		port, err := NewPort("AEAJM", "Ajman", "", "Ajman", "Untied Arab Emirates", nil, nil, nil, "", "Asia/Dubia", []string{"XYZ", "AEAJM"})
+		require.NoError(t, err)
+
+		warnings := port.Warnings()
+		require.Len(t, warnings, 3)
+		require.ErrorIs(t, warnings[0], ErrUnknownCountry)
+		require.ErrorIs(t, warnings[1], ErrInvalidUnloc)
+		require.ErrorIs(t, warnings[2], ErrUnknownTimezone)
+
+		require.NoError(t, ValidationLenient.Check(port))
+
+		err = ValidationStrict.Check(port)
+		require.ErrorIs(t, err, ErrUnknownCountry)
+		require.ErrorIs(t, err, ErrInvalidUnloc)
+		require.ErrorIs(t, err, ErrUnknownTimezone)
+	})
+
+	t.Run("unloc of another country", func(t *testing.T) {
+		port, err := NewPort("AEAJM", "Ajman", "", "Ajman", "United Arab Emirates", nil, nil, nil, "", "", []string{"OMSLL"})
+		require.NoError(t, err)
+		require.Len(t, port.Warnings(), 1)
+		require.Equal(t, "unlocs", port.Warnings()[0].Field)
+
+		require.NoError(t, port.RemoveUnloc("OMSLL"))
+		require.Empty(t, port.Warnings())
+	})
+
+	t.Run("timezones", func(t *testing.T) {
+		for _, tz := range []string{"Europe/Amsterdam", "America/Cordoba", "UTC"} {
+			port, err := NewPort("id", "name", "", "city", "Netherlands", nil, nil, nil, "", tz, nil)
+			require.NoError(t, err)
+			require.Empty(t, port.Warnings(), tz)
+		}
+
+		port, err := NewPort("id", "name", "", "city", "Netherlands", nil, nil, nil, "", "Local", nil)
+		require.NoError(t, err)
+		require.Len(t, port.Warnings(), 1)
+
+		// unknown names are not cached
+		for range 2 {
+			require.False(t, isTimezone("Europe/Atlantis"))
+		}
+		_, cached := timezones.Load("Europe/Atlantis")
+		require.False(t, cached)
+		_, cached = timezones.Load("Europe/Amsterdam")
+		require.True(t, cached)
+	})
+}
+
+func TestParseValidationMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseValidationMode("")
+	require.NoError(t, err)
+	require.Equal(t, ValidationLenient, mode)
+
+	mode, err = ParseValidationMode("strict")
+	require.NoError(t, err)
+	require.Equal(t, ValidationStrict, mode)
+
+	_, err = ParseValidationMode("pedantic")
+	require.Error(t, err)
+}
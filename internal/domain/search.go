@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchQuery is a free-text search over the names, cities, provinces and
+// aliases of the ports. Misspelled text still finds close matches.
+type SearchQuery struct {
+	Text  string
+	Limit int
+}
+
+// Normalize fills in defaults and validates the query.
+func (q SearchQuery) Normalize() (SearchQuery, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return q, fmt.Errorf("%w: search text is required", ErrInvalidQuery)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultSearchLimit
+	case q.Limit < 0:
+		return q, fmt.Errorf("%w: limit must be positive", ErrInvalidQuery)
+	case q.Limit > MaxSearchLimit:
+		q.Limit = MaxSearchLimit
+	}
+
+	return q, nil
+}
+
+// SearchHit is a port found by a search, with how relevant it is, from 0
+// to 1, and the field that matched best.
+type SearchHit struct {
+	Port  *Port
+	Score float64
+	Field string
+}
+
+const (
+	DefaultAutocompleteLimit = 10
+	MaxAutocompleteLimit     = 50
+)
+
+// AutocompleteQuery looks up the ports whose name or UN/LOCODE starts with
+// the prefix, ignoring case.
+type AutocompleteQuery struct {
+	Prefix string
+	Limit  int
+}
+
+// Normalize fills in defaults and validates the query.
+func (q AutocompleteQuery) Normalize() (AutocompleteQuery, error) {
+	q.Prefix = strings.TrimLeft(q.Prefix, " ")
+	if q.Prefix == "" {
+		return q, fmt.Errorf("%w: prefix is required", ErrInvalidQuery)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultAutocompleteLimit
+	case q.Limit < 0:
+		return q, fmt.Errorf("%w: limit must be positive", ErrInvalidQuery)
+	case q.Limit > MaxAutocompleteLimit:
+		q.Limit = MaxAutocompleteLimit
+	}
+
+	return q, nil
+}
+
+// Suggestion is a port completing an autocomplete prefix, with the field
+// and the value of it the prefix matched.
+type Suggestion struct {
+	Id    string
+	Name  string
+	Field string
+	Value string
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook subscribes a URL to the changes of the ports. Changes are posted
+// to it signed with the secret, as long as they pass the filters: an empty
+// filter lets every change through.
+type Webhook struct {
+	Id     string
+	URL    string
+	Secret string
+	// Events are the kinds of change delivered.
+	Events []ChangeKind
+	// Countries are the countries of the ports whose changes are delivered,
+	// ignoring case.
+	Countries []string
+	CreatedAt time.Time
+}
+
+// Normalize validates the URL and the filters of the webhook.
+func (w Webhook) Normalize() (Webhook, error) {
+	w.URL = strings.TrimSpace(w.URL)
+	target, err := url.Parse(w.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return w, fmt.Errorf("%w: url %q is not an absolute http or https URL", ErrInvalidWebhook, w.URL)
+	}
+
+	for _, kind := range w.Events {
+		switch kind {
+		case ChangeCreated, ChangeUpdated, ChangeDeleted:
+		default:
+			return w, fmt.Errorf("%w: unknown event %q, expected %q, %q or %q", ErrInvalidWebhook, kind, ChangeCreated, ChangeUpdated, ChangeDeleted)
+		}
+	}
+
+	countries := make([]string, 0, len(w.Countries))
+	for _, country := range w.Countries {
+		if country = strings.TrimSpace(country); country != "" {
+			countries = append(countries, country)
+		}
+	}
+	w.Countries = countries
+
+	return w, nil
+}
+
+// Matches tells whether the change passes the filters of the webhook.
+func (w Webhook) Matches(change PortChange) bool {
+	if len(w.Events) > 0 && !slices.Contains(w.Events, change.Kind) {
+		return false
+	}
+	if len(w.Countries) == 0 {
+		return true
+	}
+	if change.Port == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(w.Countries, func(country string) bool {
+		return strings.EqualFold(country, change.Port.Country())
+	})
+}
+
+// DeadLetter is a delivery of a change to a webhook that failed every
+// attempt.
+type DeadLetter struct {
+	DeliveryId string
+	WebhookId  string
+	URL        string
+	Change     PortChange
+	Attempts   int
+	Error      string
+	FailedAt   time.Time
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_Normalize(t *testing.T) {
+	t.Parallel()
+
+	webhook, err := Webhook{URL: " https://partner.example.com/ports ", Countries: []string{" Netherlands ", ""}}.Normalize()
+	require.NoError(t, err)
+	require.Equal(t, "https://partner.example.com/ports", webhook.URL)
+	require.Equal(t, []string{"Netherlands"}, webhook.Countries)
+
+	for _, invalid := range []Webhook{
+		{URL: ""},
+		{URL: "/ports"},
+		{URL: "ftp://partner.example.com"},
+		{URL: "https://partner.example.com", Events: []ChangeKind{"renamed"}},
+	} {
+		_, err := invalid.Normalize()
+		require.ErrorIs(t, err, ErrInvalidWebhook, invalid.URL)
+	}
+}
+
+func TestWebhook_Matches(t *testing.T) {
+	t.Parallel()
+
+	port, err := NewPort("NLRTM", "Rotterdam", "", "Rotterdam", "Netherlands", nil, nil, nil, "", "", nil)
+	require.NoError(t, err)
+	created := PortChange{Kind: ChangeCreated, Id: "NLRTM", Port: port}
+	updated := PortChange{Kind: ChangeUpdated, Id: "NLRTM", Port: port}
+
+	require.True(t, Webhook{}.Matches(created))
+	require.True(t, Webhook{Events: []ChangeKind{ChangeCreated}}.Matches(created))
+	require.False(t, Webhook{Events: []ChangeKind{ChangeCreated}}.Matches(updated))
+	require.True(t, Webhook{Countries: []string{"netherlands"}}.Matches(updated))
+	require.False(t, Webhook{Countries: []string{"Belgium"}}.Matches(updated))
+	require.False(t, Webhook{Countries: []string{"Netherlands"}}.Matches(PortChange{Kind: ChangeDeleted}))
+}
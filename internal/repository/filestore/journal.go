@@ -0,0 +1,291 @@
+package filestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+)
+
+const (
+	walFileName      = "ports.wal"
+	snapshotFileName = "ports.snapshot.json"
+)
+
+type op string
+
+const (
+	opPut       op = "put"
+	opDelete    op = "delete"
+	opDeleteAll op = "delete_all"
+	opBatch     op = "batch"
+	opPurge     op = "purge"
+)
+
+// entry is a single line of the write-ahead log. Entries carry the resulting
+// state rather than the request, so replaying one twice is harmless.
+type entry struct {
+	Op   op          `json:"op"`
+	Id   string      `json:"id,omitempty"`
+	Port *inmem.Port `json:"port,omitempty"`
+	// Sequence and At are the version and time of a delete, which like
+	// writes of ports take a version of their own.
+	Sequence uint64     `json:"sequence,omitempty"`
+	At       *time.Time `json:"at,omitempty"`
+	// Batch holds the entries of an opBatch, written as one line so that
+	// a batch is either replayed whole or not at all.
+	Batch []entry `json:"batch,omitempty"`
+}
+
+type snapshot struct {
+	Ports    []*inmem.Port               `json:"ports"`
+	History  map[string][]inmem.Revision `json:"history,omitempty"`
+	Sequence uint64                      `json:"sequence,omitempty"`
+}
+
+// revisionEntry is the log entry of a revision written to memory.
+func revisionEntry(revision inmem.Revision) entry {
+	if revision.Deleted {
+		return entry{Op: opDelete, Id: revision.Id, Sequence: revision.Version, At: &revision.At}
+	}
+	return entry{Op: opPut, Port: revision.Port}
+}
+
+// walFile is the file of the log, an *os.File.
+type walFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+}
+
+// errWALSyncFailed is returned by appends once a sync of the log failed.
+var errWALSyncFailed = errors.New("wal is unusable after a failed sync")
+
+// wal is an append-only log of json encoded entries, one per line. Appends
+// are written right away and synced apart, see syncTo, so that a sync is
+// shared by the appends made while the previous one was under way.
+type wal struct {
+	file    walFile
+	sync    bool
+	entries int
+	// size is the length of the log up to its last complete entry, what a
+	// failed append is cut back to.
+	size int64
+
+	// appended counts the entries appended, synced those on disk.
+	appended atomic.Uint64
+	// syncing serialises syncs, and guards synced and syncErr.
+	syncing sync.Mutex
+	synced  uint64
+	// syncErr is the error of a failed sync, after which it is unknown
+	// which entries made it to disk, so none is taken any more.
+	syncErr    error
+	syncFailed atomic.Bool
+}
+
+func openWAL(dir string, sync bool) (*wal, error) {
+	file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("stat wal: %w", err)
+	}
+
+	return &wal{
+		file: file,
+		sync: sync,
+		size: info.Size(),
+	}, nil
+}
+
+// append writes the entry to the log and returns its number, which syncTo
+// takes to make sure it is on disk.
+func (w *wal) append(e entry) (uint64, error) {
+	if w.syncFailed.Load() {
+		return 0, errWALSyncFailed
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("marshal wal entry: %w", err)
+	}
+
+	line = append(line, '\n')
+	_, err = w.file.Write(line)
+	if err != nil {
+		return 0, w.cutBack(fmt.Errorf("write wal entry: %w", err))
+	}
+
+	w.entries++
+	w.size += int64(len(line))
+	return w.appended.Add(1), nil
+}
+
+// syncTo returns once the entries up to the given number are on disk. A
+// sync under way may have started before the entry was written, so it
+// waits for that one and, unless it covered the entry, syncs every entry
+// appended by then.
+func (w *wal) syncTo(n uint64) error {
+	if !w.sync {
+		return nil
+	}
+
+	w.syncing.Lock()
+	defer w.syncing.Unlock()
+
+	if w.synced >= n {
+		return nil
+	}
+	if w.syncErr != nil {
+		return w.syncErr
+	}
+
+	appended := w.appended.Load()
+	err := w.file.Sync()
+	if err != nil {
+		w.syncErr = fmt.Errorf("sync wal: %w", err)
+		w.syncFailed.Store(true)
+		return w.syncErr
+	}
+
+	w.synced = appended
+	return nil
+}
+
+// cutBack truncates the log to its last complete entry after a failed
+// append, so that a partly written line does not end up in the middle of
+// the log once the next append succeeds.
+func (w *wal) cutBack(err error) error {
+	truncErr := w.file.Truncate(w.size)
+	if truncErr != nil {
+		return errors.Join(err, fmt.Errorf("truncate wal after failed append: %w", truncErr))
+	}
+
+	return err
+}
+
+// replay reads every entry from the start of the log and passes it to apply.
+// A torn last line, left behind by a crash in the middle of a write, is cut off.
+func (w *wal) replay(apply func(entry)) error {
+	_, err := w.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+
+	reader := bufio.NewReader(w.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			w.size = offset
+			if len(line) > 0 {
+				return w.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+
+		var e entry
+		err = json.Unmarshal(line, &e)
+		if err != nil {
+			return fmt.Errorf("corrupted wal entry at offset %d: %w", offset, err)
+		}
+
+		apply(e)
+		w.entries++
+		offset += int64(len(line))
+	}
+}
+
+// reset empties the log once its entries are in a snapshot, which makes
+// them as good as synced. Callers must keep appends off.
+func (w *wal) reset() error {
+	w.syncing.Lock()
+	defer w.syncing.Unlock()
+
+	err := w.file.Truncate(0)
+	if err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+
+	w.entries = 0
+	w.size = 0
+	w.synced = w.appended.Load()
+	return nil
+}
+
+func (w *wal) close() error {
+	w.syncing.Lock()
+	defer w.syncing.Unlock()
+
+	return w.file.Close()
+}
+
+func readSnapshot(dir string) (*snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &snapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var s snapshot
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	return &s, nil
+}
+
+// writeSnapshot writes the snapshot to a temporary file and renames it over
+// the previous one, so a crash never leaves a half written snapshot behind.
+func writeSnapshot(dir string, s *snapshot) error {
+	tmp, err := os.CreateTemp(dir, snapshotFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	err = json.NewEncoder(tmp).Encode(s)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	err = tmp.Sync()
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), filepath.Join(dir, snapshotFileName))
+	if err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+
+	return nil
+}
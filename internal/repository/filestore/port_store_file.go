@@ -0,0 +1,545 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+)
+
+// PortStore keeps ports in memory and makes them durable with a write-ahead
+// log that is periodically compacted into a snapshot. On start the snapshot
+// and the log are replayed to rebuild the in-memory state.
+type PortStore struct {
+	dir string
+	mem *inmem.PortStore
+	wal *wal
+
+	// mu serialises writes so that the log has the same order as memory.
+	// Reads hold it too, so that they never see a write that is not in the
+	// log yet and may still be rolled back. Writes sync the log once they
+	// let go of it, so that reads do not wait for the disk, and writes made
+	// meanwhile share the next sync.
+	mu sync.RWMutex
+	// compacting serialises compactions, which only hold mu for reading.
+	compacting sync.Mutex
+	// onChange are called with every change once it is logged, see OnChange.
+	onChange []func(change domain.PortChange)
+	// historyLimit is how many revisions of a port are kept, see
+	// LimitHistory. Memory keeps every revision of a write until it is
+	// logged, so that the write can be rolled back.
+	historyLimit int
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewPortStore opens (or creates) a store in dir and compacts the log into
+// a snapshot every snapshotInterval.
+func NewPortStore(dir string, snapshotInterval time.Duration) (*PortStore, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+
+	s, err := readSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := openWAL(dir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	mem := inmem.NewPortStore()
+	mem.Restore(s.Ports, s.History)
+	mem.AdvanceSequence(s.Sequence)
+
+	err = w.replay(func(e entry) {
+		applyEntry(mem, e)
+	})
+	if err != nil {
+		_ = w.close()
+		return nil, err
+	}
+
+	ps := &PortStore{
+		dir:     dir,
+		mem:     mem,
+		wal:     w,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go ps.compactPeriodically(snapshotInterval)
+
+	return ps, nil
+}
+
+func applyEntry(mem *inmem.PortStore, e entry) {
+	var at time.Time
+	if e.At != nil {
+		at = *e.At
+	}
+
+	switch e.Op {
+	case opPut:
+		mem.Put(e.Port)
+	case opDelete:
+		// the port may already be gone if the snapshot was taken after this entry
+		mem.Remove(e.Id, e.Sequence, at)
+	case opDeleteAll:
+		mem.RemoveAll(e.Sequence, at)
+	case opPurge:
+		mem.Purge(e.Id)
+	case opBatch:
+		for _, batchEntry := range e.Batch {
+			applyEntry(mem, batchEntry)
+		}
+	default:
+		log.Errorf("unknown wal entry op: %s", e.Op)
+	}
+}
+
+func (ps *PortStore) GetPort(ctx context.Context, id string) (*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.GetPort(ctx, id)
+}
+
+func (ps *PortStore) GetPortRecord(ctx context.Context, id string) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.GetPortRecord(ctx, id)
+}
+
+func (ps *PortStore) GetPortAsOf(ctx context.Context, id string, at time.Time) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.GetPortAsOf(ctx, id, at)
+}
+
+func (ps *PortStore) PortHistory(ctx context.Context, id string) ([]domain.PortRevision, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.PortHistory(ctx, id)
+}
+
+func (ps *PortStore) GetDeletedPort(ctx context.Context, id string) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.GetDeletedPort(ctx, id)
+}
+
+func (ps *PortStore) CountPorts(ctx context.Context) (int, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.CountPorts(ctx)
+}
+
+func (ps *PortStore) ListPorts(ctx context.Context, query domain.PortQuery) (*domain.PortPage, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.ListPorts(ctx, query)
+}
+
+func (ps *PortStore) GetPortByUnloc(ctx context.Context, unloc string) (*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.GetPortByUnloc(ctx, unloc)
+}
+
+func (ps *PortStore) FindPortsByCode(ctx context.Context, code string) ([]*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.FindPortsByCode(ctx, code)
+}
+
+func (ps *PortStore) FindPortsByAlias(ctx context.Context, alias string) ([]*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.FindPortsByAlias(ctx, alias)
+}
+
+func (ps *PortStore) NearestPorts(ctx context.Context, lat, lon float64, k int) ([]domain.NearbyPort, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.NearestPorts(ctx, lat, lon, k)
+}
+
+func (ps *PortStore) PortsWithin(ctx context.Context, box domain.BoundingBox) ([]domain.NearbyPort, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.PortsWithin(ctx, box)
+}
+
+func (ps *PortStore) SearchPorts(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.SearchPorts(ctx, query)
+}
+
+func (ps *PortStore) AutocompletePorts(ctx context.Context, query domain.AutocompleteQuery) ([]domain.Suggestion, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.mem.AutocompletePorts(ctx, query)
+}
+
+// ForEachPort collects the ports under the read lock, and calls fn once it
+// is released so that a slow fn does not hold writes off.
+func (ps *PortStore) ForEachPort(ctx context.Context, fn func(port *domain.Port) error) error {
+	var ports []*domain.Port
+	ps.mu.RLock()
+	err := ps.mem.ForEachPort(ctx, func(port *domain.Port) error {
+		ports = append(ports, port)
+		return nil
+	})
+	ps.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := fn(port)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ps *PortStore) CreateOrUpdatePort(ctx context.Context, port *domain.Port) error {
+	if port == nil {
+		return domain.ErrNil
+	}
+
+	return ps.write(func() error {
+		return ps.mem.CreateOrUpdatePort(ctx, port)
+	}, port.Id())
+}
+
+func (ps *PortStore) CreatePort(ctx context.Context, port *domain.Port) error {
+	if port == nil {
+		return domain.ErrNil
+	}
+
+	return ps.write(func() error {
+		return ps.mem.CreatePort(ctx, port)
+	}, port.Id())
+}
+
+func (ps *PortStore) UpdatePortIfVersion(ctx context.Context, port *domain.Port, version uint64) error {
+	if port == nil {
+		return domain.ErrNil
+	}
+
+	return ps.write(func() error {
+		return ps.mem.UpdatePortIfVersion(ctx, port, version)
+	}, port.Id())
+}
+
+func (ps *PortStore) DeletePortById(ctx context.Context, id string) error {
+	return ps.write(func() error {
+		return ps.mem.DeletePortById(ctx, id)
+	}, id)
+}
+
+func (ps *PortStore) DeletePortIfVersion(ctx context.Context, id string, version uint64) error {
+	return ps.write(func() error {
+		return ps.mem.DeletePortIfVersion(ctx, id, version)
+	}, id)
+}
+
+func (ps *PortStore) RestorePort(ctx context.Context, id string) error {
+	return ps.write(func() error {
+		return ps.mem.RestorePort(ctx, id)
+	}, id)
+}
+
+// PurgeDeletedPorts logs the purge before dropping the tombstones, so
+// there is nothing to roll back when the log cannot be written.
+func (ps *PortStore) PurgeDeletedPorts(ctx context.Context, before time.Time) ([]string, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	ids, logged, err := ps.purge(before)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ps.wal.syncTo(logged)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// purge does the logging and dropping of PurgeDeletedPorts, under the lock.
+// It returns the number of the log entry to sync.
+func (ps *PortStore) purge(before time.Time) ([]string, uint64, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ids := ps.mem.DeletedBefore(before)
+	if len(ids) == 0 {
+		return ids, 0, nil
+	}
+
+	entries := make([]entry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, entry{Op: opPurge, Id: id})
+	}
+
+	logged, err := ps.wal.append(entry{Op: opBatch, Batch: entries})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ps.mem.Purge(ids...)
+
+	return ids, logged, nil
+}
+
+func (ps *PortStore) ApplyBatch(ctx context.Context, batch *domain.PortBatch) error {
+	if batch == nil {
+		return domain.ErrNil
+	}
+
+	ids := make([]string, 0, batch.Len())
+	for _, op := range batch.Ops() {
+		ids = append(ids, op.Id)
+	}
+
+	return ps.write(func() error {
+		return ps.mem.ApplyBatch(ctx, batch)
+	}, ids...)
+}
+
+// write applies apply to memory and logs the revisions it wrote for the
+// given ports, as one entry so that they are replayed all or none. When
+// the log cannot be written, memory is rolled back to keep it in line
+// with what is on disk. The entry is synced once the lock is let go of.
+func (ps *PortStore) write(apply func() error, ids ...string) error {
+	logged, err := ps.logWrite(apply, ids...)
+	if err != nil {
+		return err
+	}
+
+	return ps.wal.syncTo(logged)
+}
+
+// logWrite does the work of write under the lock, and returns the number
+// of the log entry to sync, zero when apply wrote nothing.
+func (ps *PortStore) logWrite(apply func() error, ids ...string) (uint64, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	sequence := ps.mem.Sequence()
+
+	err := apply()
+	if err != nil {
+		return 0, err
+	}
+
+	revisions := ps.mem.RevisionsSince(sequence, ids...)
+	entries := make([]entry, 0, len(revisions))
+	for _, revision := range revisions {
+		entries = append(entries, revisionEntry(revision))
+	}
+
+	var e entry
+	switch len(entries) {
+	case 0:
+		return 0, nil
+	case 1:
+		e = entries[0]
+	default:
+		e = entry{Op: opBatch, Batch: entries}
+	}
+
+	logged, err := ps.wal.append(e)
+	if err != nil {
+		ps.mem.Rollback(sequence)
+		return 0, err
+	}
+
+	ps.notify(sequence, ids...)
+	ps.mem.TrimHistory(ps.historyLimit, ids...)
+	return logged, nil
+}
+
+func (ps *PortStore) DeleteAllPorts(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	logged, err := ps.deleteAll()
+	if err != nil {
+		return err
+	}
+
+	return ps.wal.syncTo(logged)
+}
+
+// deleteAll does the work of DeleteAllPorts under the lock, and returns the
+// number of the log entry to sync.
+func (ps *PortStore) deleteAll() (uint64, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	// memory is only written under ps.mu, so the next version is known
+	sequence := ps.mem.Sequence()
+	version, at := sequence+1, time.Now()
+	ps.mem.RemoveAll(version, at)
+
+	logged, err := ps.wal.append(entry{Op: opDeleteAll, Sequence: version, At: &at})
+	if err != nil {
+		ps.mem.Rollback(sequence)
+		return 0, err
+	}
+
+	ps.notify(sequence)
+	ps.mem.TrimHistory(ps.historyLimit)
+	return logged, nil
+}
+
+// LimitHistory keeps at most the given number of revisions per port, zero
+// keeping them all. The histories already longer are trimmed right away,
+// and on disk with the next snapshot.
+func (ps *PortStore) LimitHistory(revisions int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.historyLimit = revisions
+	ps.mem.TrimHistory(revisions)
+}
+
+// OnChange registers fn to be called with every change the store makes from
+// now on, in the order it makes them, once the change is logged. fn is
+// called with the write lock held, so it must be quick and must not call
+// back into the store.
+func (ps *PortStore) OnChange(fn func(change domain.PortChange)) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.onChange = append(ps.onChange, fn)
+}
+
+// notify hands the changes made to the given ports after sequence, or to
+// every port when none is given, to the OnChange functions. Callers must
+// hold ps.mu.
+func (ps *PortStore) notify(sequence uint64, ids ...string) {
+	if len(ps.onChange) == 0 {
+		return
+	}
+
+	changes, err := ps.mem.ChangesSince(sequence, ids...)
+	if err != nil {
+		log.Errorf("changes after version %d dropped: %v", sequence, err)
+		return
+	}
+	for _, change := range changes {
+		for _, fn := range ps.onChange {
+			fn(change)
+		}
+	}
+}
+
+// Compact writes the current state into a snapshot and truncates the log.
+// Writes wait for it to finish, reads do not.
+func (ps *PortStore) Compact() error {
+	ps.compacting.Lock()
+	defer ps.compacting.Unlock()
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.compact()
+}
+
+// compact does the work of Compact. Callers must hold ps.compacting, and
+// ps.mu at least for reading.
+func (ps *PortStore) compact() error {
+	if ps.wal.entries == 0 {
+		return nil
+	}
+
+	err := writeSnapshot(ps.dir, &snapshot{
+		Ports:    ps.mem.Snapshot(),
+		History:  ps.mem.History(),
+		Sequence: ps.mem.Sequence(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ps.wal.reset()
+}
+
+func (ps *PortStore) compactPeriodically(interval time.Duration) {
+	defer close(ps.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ps.stop:
+			return
+		case <-ticker.C:
+			err := ps.Compact()
+			if err != nil {
+				log.Errorf("could not compact port store: %v", err)
+			}
+		}
+	}
+}
+
+// Close stops the background compaction, takes a final snapshot and closes
+// the log. Closing again returns the result of the first Close.
+func (ps *PortStore) Close() error {
+	ps.closeOnce.Do(func() {
+		close(ps.stop)
+		<-ps.stopped
+
+		ps.compacting.Lock()
+		defer ps.compacting.Unlock()
+
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+
+		ps.closeErr = errors.Join(ps.compact(), ps.wal.close())
+	})
+
+	return ps.closeErr
+}
@@ -0,0 +1,616 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestPortStore_CreateOrUpdatePort(t *testing.T) {
+	t.Parallel()
+
+	store := newTestPortStore(t, t.TempDir())
+
+	t.Run("create port", func(t *testing.T) {
+		t.Parallel()
+
+		randomPort := newRandomDomainPort(t)
+
+		createRandomPortAndVerify(t, store, randomPort)
+
+		err := store.CreateOrUpdatePort(context.Background(), randomPort)
+		require.NoError(t, err)
+
+		port, err := store.GetPort(context.Background(), randomPort.Id())
+		require.NoError(t, err)
+		require.Equal(t, port, randomPort)
+	})
+
+	t.Run("update port", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		randomPort := newRandomDomainPort(t)
+
+		err = store.CreateOrUpdatePort(context.Background(), randomPort)
+		require.NoError(t, err)
+
+		beforeUpdatedPort, err := store.GetPort(context.Background(), randomPort.Id())
+		require.NoError(t, err)
+		require.Equal(t, beforeUpdatedPort, randomPort)
+
+		err = beforeUpdatedPort.SetName("updated name")
+		require.NoError(t, err)
+
+		err = store.CreateOrUpdatePort(context.Background(), beforeUpdatedPort)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+
+		reopened := newTestPortStore(t, dir)
+
+		updatedPort, err := reopened.GetPort(context.Background(), randomPort.Id())
+		require.NoError(t, err)
+		require.Equal(t, "updated name", updatedPort.Name())
+		require.Equal(t, randomPort.City(), updatedPort.City())
+	})
+
+	t.Run("delete port", func(t *testing.T) {
+		t.Parallel()
+		store := newTestPortStore(t, t.TempDir())
+
+		randomPort := newRandomDomainPort(t)
+
+		createRandomPortAndVerify(t, store, randomPort)
+
+		err := store.DeletePortById(context.Background(), randomPort.Id())
+		require.NoError(t, err)
+
+		_, err = store.GetPort(context.Background(), randomPort.Id())
+		require.Error(t, err)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("delete all ports", func(t *testing.T) {
+		t.Parallel()
+
+		store := newTestPortStore(t, t.TempDir())
+
+		randomPort1 := newRandomDomainPort(t)
+		randomPort2 := newRandomDomainPort(t)
+		randomPort3 := newRandomDomainPort(t)
+
+		createRandomPortAndVerify(t, store, randomPort1)
+		createRandomPortAndVerify(t, store, randomPort2)
+		createRandomPortAndVerify(t, store, randomPort3)
+
+		count, err := store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+
+		err = store.DeleteAllPorts(context.Background())
+		require.NoError(t, err)
+
+		count, err = store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+
+		_, err = store.GetPort(context.Background(), randomPort1.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("nil port", func(t *testing.T) {
+		t.Parallel()
+
+		err := store.CreateOrUpdatePort(context.Background(), nil)
+		require.ErrorIs(t, err, domain.ErrNil)
+	})
+}
+
+func TestPortStore_Reopen(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replays wal", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		kept := newRandomDomainPort(t)
+		deleted := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, kept)
+		createRandomPortAndVerify(t, store, deleted)
+		require.NoError(t, store.DeletePortById(context.Background(), deleted.Id()))
+
+		// simulate a crash: the log is not compacted into a snapshot
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		port, err := reopened.GetPort(context.Background(), kept.Id())
+		require.NoError(t, err)
+		require.Equal(t, kept, port)
+
+		_, err = reopened.GetPort(context.Background(), deleted.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("restores snapshot and wal", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		inSnapshot := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, inSnapshot)
+		require.NoError(t, store.Compact())
+
+		inWAL := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, inWAL)
+		require.NoError(t, store.Close())
+
+		reopened := newTestPortStore(t, dir)
+
+		count, err := reopened.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		for _, expected := range []*domain.Port{inSnapshot, inWAL} {
+			port, err := reopened.GetPort(context.Background(), expected.Id())
+			require.NoError(t, err)
+			require.Equal(t, expected, port)
+		}
+	})
+
+	t.Run("replays delete all", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		createRandomPortAndVerify(t, store, newRandomDomainPort(t))
+		require.NoError(t, store.Compact())
+		require.NoError(t, store.DeleteAllPorts(context.Background()))
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		count, err := reopened.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("replays batch", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		deleted := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, deleted)
+
+		created := newRandomDomainPort(t)
+		batch := domain.NewPortBatch()
+		require.NoError(t, batch.Upsert(created))
+		batch.Delete(deleted.Id())
+		require.NoError(t, store.ApplyBatch(context.Background(), batch))
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		port, err := reopened.GetPort(context.Background(), created.Id())
+		require.NoError(t, err)
+		require.Equal(t, created, port)
+
+		_, err = reopened.GetPort(context.Background(), deleted.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("keeps versions", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		kept := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, kept)
+		require.NoError(t, store.Compact())
+
+		deleted := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, deleted)
+		deletedRecord, err := store.GetPortRecord(context.Background(), deleted.Id())
+		require.NoError(t, err)
+		require.NoError(t, store.DeletePortById(context.Background(), deleted.Id()))
+
+		keptRecord, err := store.GetPortRecord(context.Background(), kept.Id())
+		require.NoError(t, err)
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		record, err := reopened.GetPortRecord(context.Background(), kept.Id())
+		require.NoError(t, err)
+		require.Equal(t, keptRecord.Version, record.Version)
+
+		// the version of the deleted port is not handed out again
+		createRandomPortAndVerify(t, reopened, deleted)
+		record, err = reopened.GetPortRecord(context.Background(), deleted.Id())
+		require.NoError(t, err)
+		require.Greater(t, record.Version, deletedRecord.Version)
+
+		err = reopened.UpdatePortIfVersion(context.Background(), kept, keptRecord.Version)
+		require.NoError(t, err)
+		err = reopened.UpdatePortIfVersion(context.Background(), kept, keptRecord.Version)
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	})
+
+	t.Run("keeps history", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.Compact())
+
+		// one revision in the snapshot, the update and the delete in the log
+		require.NoError(t, port.SetName("updated name"))
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.DeleteAllPorts(context.Background()))
+
+		history, err := store.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		replayed, err := reopened.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, replayed, len(history))
+		for i := range history {
+			require.Equal(t, history[i].Version, replayed[i].Version)
+			require.True(t, history[i].At.Equal(replayed[i].At))
+			require.Equal(t, history[i].Deleted, replayed[i].Deleted)
+			require.Equal(t, history[i].Port, replayed[i].Port)
+		}
+	})
+
+	t.Run("keeps the latest revisions", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+		store.LimitHistory(1)
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, port.SetName("updated name"))
+		createRandomPortAndVerify(t, store, port)
+
+		// a write that cannot be logged goes back to the revision kept
+		file := store.wal.file
+		store.wal.file = &tornFile{walFile: file}
+		require.NoError(t, port.SetName("failed name"))
+		require.Error(t, store.CreateOrUpdatePort(context.Background(), port))
+		store.wal.file = file
+
+		stored, err := store.GetPort(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Equal(t, "updated name", stored.Name())
+
+		require.NoError(t, store.Compact())
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		history, err := reopened.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		require.Equal(t, "updated name", history[0].Port.Name())
+	})
+
+	t.Run("keeps tombstones", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		inSnapshot := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, inSnapshot)
+		require.NoError(t, store.DeletePortById(context.Background(), inSnapshot.Id()))
+		require.NoError(t, store.Compact())
+
+		purged := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, purged)
+		require.NoError(t, store.DeletePortById(context.Background(), purged.Id()))
+
+		inWAL := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, inWAL)
+		cutoff := time.Now()
+		require.NoError(t, store.DeletePortById(context.Background(), inWAL.Id()))
+
+		ids, err := store.PurgeDeletedPorts(context.Background(), cutoff)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{inSnapshot.Id(), purged.Id()}, ids)
+		require.NoError(t, store.wal.close())
+
+		reopened := newTestPortStore(t, dir)
+
+		count, err := reopened.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		for _, id := range ids {
+			_, err = reopened.GetDeletedPort(context.Background(), id)
+			require.ErrorIs(t, err, domain.ErrNotFound)
+			_, err = reopened.PortHistory(context.Background(), id)
+			require.ErrorIs(t, err, domain.ErrNotFound)
+		}
+
+		require.NoError(t, reopened.RestorePort(context.Background(), inWAL.Id()))
+		port, err := reopened.GetPort(context.Background(), inWAL.Id())
+		require.NoError(t, err)
+		require.Equal(t, inWAL, port)
+	})
+
+	t.Run("cuts off torn wal entry", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		store, err := NewPortStore(dir, time.Hour)
+		require.NoError(t, err)
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.wal.close())
+
+		file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"op":"put","port":{"Id":"torn`)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		reopened := newTestPortStore(t, dir)
+
+		count, err := reopened.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		createRandomPortAndVerify(t, reopened, newRandomDomainPort(t))
+	})
+}
+
+func TestPortStore_OnChange(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// not closed at the end, its log is closed to make a write fail
+	store, err := NewPortStore(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+
+	var changes []domain.PortChange
+	store.OnChange(func(change domain.PortChange) {
+		changes = append(changes, change)
+	})
+
+	kept := newRandomDomainPort(t)
+	deleted := newRandomDomainPort(t)
+	createRandomPortAndVerify(t, store, kept)
+	batch := domain.NewPortBatch()
+	require.NoError(t, batch.Upsert(deleted))
+	require.NoError(t, store.ApplyBatch(ctx, batch))
+	require.NoError(t, store.DeletePortById(ctx, deleted.Id()))
+	require.NoError(t, store.DeleteAllPorts(ctx))
+
+	require.Len(t, changes, 4)
+	require.Equal(t, domain.ChangeCreated, changes[0].Kind)
+	require.Equal(t, kept, changes[0].Port)
+	require.Equal(t, domain.ChangeCreated, changes[1].Kind)
+	require.Equal(t, domain.ChangeDeleted, changes[2].Kind)
+	require.Equal(t, deleted, changes[2].Port)
+	require.Equal(t, domain.ChangeDeleted, changes[3].Kind)
+	require.Equal(t, kept.Id(), changes[3].Id)
+
+	// a write that cannot be logged is rolled back and is no change
+	require.NoError(t, store.wal.close())
+	require.Error(t, store.CreateOrUpdatePort(ctx, newRandomDomainPort(t)))
+	require.Len(t, changes, 4)
+}
+
+func TestPortStore_FailedAppend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewPortStore(dir, time.Hour)
+	require.NoError(t, err)
+
+	before := newRandomDomainPort(t)
+	createRandomPortAndVerify(t, store, before)
+
+	// the append writes half its line before failing
+	file := store.wal.file
+	store.wal.file = &tornFile{walFile: file}
+	failed := newRandomDomainPort(t)
+	require.Error(t, store.CreateOrUpdatePort(ctx, failed))
+	_, err = store.GetPort(ctx, failed.Id())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	store.wal.file = file
+	after := newRandomDomainPort(t)
+	createRandomPortAndVerify(t, store, after)
+
+	// simulate a crash, the log is replayed past the failed append
+	require.NoError(t, store.wal.close())
+	reopened := newTestPortStore(t, dir)
+
+	for _, expected := range []*domain.Port{before, after} {
+		port, err := reopened.GetPort(ctx, expected.Id())
+		require.NoError(t, err)
+		require.Equal(t, expected, port)
+	}
+	_, err = reopened.GetPort(ctx, failed.Id())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestPortStore_Sync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reads do not wait for it", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		store := newTestPortStore(t, t.TempDir())
+		stored := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, stored)
+
+		file := &slowSyncFile{walFile: store.wal.file, syncing: make(chan struct{}), release: make(chan struct{})}
+		store.wal.file = file
+
+		written := make(chan error, 2)
+		go func() {
+			written <- store.CreateOrUpdatePort(ctx, newRandomDomainPort(t))
+		}()
+		<-file.syncing
+
+		// the sync is under way: reads go on, and a write shares the next sync
+		port, err := store.GetPort(ctx, stored.Id())
+		require.NoError(t, err)
+		require.Equal(t, stored, port)
+		go func() {
+			written <- store.CreateOrUpdatePort(ctx, newRandomDomainPort(t))
+		}()
+		require.Eventually(t, func() bool {
+			return store.wal.appended.Load() == 3
+		}, 5*time.Second, time.Millisecond)
+
+		close(file.release)
+		require.NoError(t, <-written)
+		require.NoError(t, <-written)
+		require.Equal(t, 2, int(file.syncs.Load()))
+	})
+
+	t.Run("failed sync", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		store := newTestPortStore(t, t.TempDir())
+		stored := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, stored)
+
+		file := store.wal.file
+		store.wal.file = &failedSyncFile{walFile: file}
+		require.Error(t, store.CreateOrUpdatePort(ctx, newRandomDomainPort(t)))
+		store.wal.file = file
+
+		// whether the entry made it to disk is unknown, so the log takes no more
+		require.ErrorIs(t, store.CreateOrUpdatePort(ctx, newRandomDomainPort(t)), errWALSyncFailed)
+		port, err := store.GetPort(ctx, stored.Id())
+		require.NoError(t, err)
+		require.Equal(t, stored, port)
+	})
+}
+
+func TestPortStore_CloseTwice(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewPortStore(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	createRandomPortAndVerify(t, store, newRandomDomainPort(t))
+
+	require.NoError(t, store.Close())
+	require.NoError(t, store.Close())
+}
+
+// tornFile writes half of what it is given and fails, as a full disk does.
+type tornFile struct {
+	walFile
+}
+
+func (f *tornFile) Write(p []byte) (int, error) {
+	n, err := f.walFile.Write(p[:len(p)/2])
+	if err != nil {
+		return n, err
+	}
+
+	return n, errors.New("no space left on device")
+}
+
+// slowSyncFile holds the first sync until release is closed, telling on
+// syncing once it started.
+type slowSyncFile struct {
+	walFile
+	syncing chan struct{}
+	release chan struct{}
+	syncs   atomic.Int32
+}
+
+func (f *slowSyncFile) Sync() error {
+	if f.syncs.Add(1) == 1 {
+		close(f.syncing)
+		<-f.release
+	}
+
+	return f.walFile.Sync()
+}
+
+// failedSyncFile fails to sync, as a failing disk does.
+type failedSyncFile struct {
+	walFile
+}
+
+func (f *failedSyncFile) Sync() error {
+	return errors.New("input/output error")
+}
+
+func newTestPortStore(t *testing.T, dir string) *PortStore {
+	t.Helper()
+
+	store, err := NewPortStore(dir, time.Hour)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	return store
+}
+
+func newRandomDomainPort(t *testing.T) *domain.Port {
+	t.Helper()
+	randomID := uuid.New().String()
+	port, err := domain.NewPort(randomID, randomID, randomID, randomID, randomID, []string{randomID}, nil, []float64{1.0, 2.0}, randomID, randomID, []string{randomID})
+	require.NoError(t, err)
+	return port
+}
+
+func createRandomPortAndVerify(t *testing.T, store *PortStore, port *domain.Port) {
+	t.Helper()
+
+	err := store.CreateOrUpdatePort(context.Background(), port)
+	require.NoError(t, err)
+
+	storedPort, err := store.GetPort(context.Background(), port.Id())
+	require.NoError(t, err)
+	require.Equal(t, storedPort, port)
+}
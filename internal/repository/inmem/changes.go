@@ -0,0 +1,118 @@
+package inmem
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+// OnChange registers fn to be called with every change the store makes from
+// now on, in the order it makes them. fn is called with the write lock held,
+// so it must be quick and must not call back into the store.
+func (ps *PortStore) OnChange(fn func(change domain.PortChange)) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.onChange = append(ps.onChange, fn)
+}
+
+// notify hands the change the revision made to the OnChange functions.
+// earlier are the revisions of the port before it. Callers must hold the
+// write lock.
+func (ps *PortStore) notify(earlier []Revision, revision Revision) {
+	if len(ps.onChange) == 0 {
+		return
+	}
+
+	change, err := changeOf(earlier, revision)
+	if err != nil {
+		log.Errorf("change of port %s at version %d dropped: %v", revision.Id, revision.Version, err)
+		return
+	}
+	for _, fn := range ps.onChange {
+		fn(change)
+	}
+}
+
+// ChangesSince returns the changes made after sequence to the given ports,
+// or to every port when none is given, in the order they were made.
+func (ps *PortStore) ChangesSince(sequence uint64, ids ...string) ([]domain.PortChange, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if len(ids) == 0 {
+		ids = make([]string, 0, len(ps.history))
+		for id := range ps.history {
+			ids = append(ids, id)
+		}
+	}
+
+	var changes []domain.PortChange
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		revisions := ps.history[id]
+		i := sort.Search(len(revisions), func(i int) bool {
+			return revisions[i].Version > sequence
+		})
+		for ; i < len(revisions); i++ {
+			change, err := changeOf(revisions[:i], revisions[i])
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	// deleting every port deletes them all at the same version
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Version != changes[j].Version {
+			return changes[i].Version < changes[j].Version
+		}
+		return changes[i].Id < changes[j].Id
+	})
+
+	return changes, nil
+}
+
+// changeOf returns the change the revision made, given the revisions of the
+// port before it.
+func changeOf(earlier []Revision, revision Revision) (domain.PortChange, error) {
+	change := domain.PortChange{
+		Id:      revision.Id,
+		Version: revision.Version,
+		At:      revision.At,
+	}
+
+	port := revision.Port
+	switch {
+	case revision.Deleted:
+		change.Kind = domain.ChangeDeleted
+		for i := len(earlier) - 1; i >= 0; i-- {
+			if !earlier[i].Deleted {
+				port = earlier[i].Port
+				break
+			}
+		}
+	case len(earlier) == 0 || earlier[len(earlier)-1].Deleted:
+		change.Kind = domain.ChangeCreated
+	default:
+		change.Kind = domain.ChangeUpdated
+	}
+
+	if port != nil {
+		domainPort, err := portStoreToDomain(port)
+		if err != nil {
+			return change, fmt.Errorf("portStoreToDomain failed: %w", err)
+		}
+		change.Port = domainPort
+	}
+
+	return change, nil
+}
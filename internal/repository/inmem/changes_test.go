@@ -0,0 +1,61 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestPortStore_OnChange(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewPortStore()
+
+	var changes []domain.PortChange
+	store.OnChange(func(change domain.PortChange) {
+		changes = append(changes, change)
+	})
+
+	port := newRandomDomainPort(t)
+	other := newRandomDomainPort(t)
+	createRandomPortAndVerify(t, store, port)
+	require.NoError(t, port.SetName("renamed"))
+	require.NoError(t, store.CreateOrUpdatePort(ctx, port))
+	require.NoError(t, store.DeletePortById(ctx, port.Id()))
+	require.NoError(t, store.RestorePort(ctx, port.Id()))
+	createRandomPortAndVerify(t, store, other)
+	require.NoError(t, store.DeleteAllPorts(ctx))
+
+	kinds := make([]domain.ChangeKind, 0, len(changes))
+	for _, change := range changes {
+		kinds = append(kinds, change.Kind)
+	}
+	require.Equal(t, []domain.ChangeKind{
+		domain.ChangeCreated,
+		domain.ChangeUpdated,
+		domain.ChangeDeleted,
+		domain.ChangeCreated,
+		domain.ChangeCreated,
+		domain.ChangeDeleted,
+		domain.ChangeDeleted,
+	}, kinds)
+
+	// a deletion carries the port as it was before it
+	require.Equal(t, port, changes[2].Port)
+	require.Equal(t, port.Id(), changes[2].Id)
+	require.Greater(t, changes[2].Version, changes[1].Version)
+	require.Equal(t, changes[5].Version, changes[6].Version)
+
+	since, err := store.ChangesSince(changes[3].Version)
+	require.NoError(t, err)
+	require.Len(t, since, 3)
+	require.Equal(t, other, since[0].Port)
+
+	since, err = store.ChangesSince(0, other.Id())
+	require.NoError(t, err)
+	require.Equal(t, []domain.ChangeKind{domain.ChangeCreated, domain.ChangeDeleted}, []domain.ChangeKind{since[0].Kind, since[1].Kind})
+}
@@ -0,0 +1,258 @@
+package inmem
+
+import (
+	"math"
+	"sort"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const (
+	latCells = 180
+	lonCells = 360
+)
+
+// kmPerDegree is the length of one degree of latitude.
+const kmPerDegree = domain.EarthRadiusKm * math.Pi / 180
+
+type geoCell struct {
+	lat int
+	lon int
+}
+
+type geoPoint struct {
+	lat float64
+	lon float64
+}
+
+// geoIndex is a grid of one degree cells holding the ids of the ports
+// located in each of them.
+type geoIndex struct {
+	cells  map[geoCell]map[string]struct{}
+	points map[string]geoPoint
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{
+		cells:  make(map[geoCell]map[string]struct{}),
+		points: make(map[string]geoPoint),
+	}
+}
+
+// portLocation reads the stored [longitude, latitude] pair of the port.
+func portLocation(port *Port) (geoPoint, bool) {
+	if len(port.Coordinates) != 2 {
+		return geoPoint{}, false
+	}
+
+	p := geoPoint{lat: port.Coordinates[1], lon: port.Coordinates[0]}
+	if domain.ValidateLatLon(p.lat, p.lon) != nil {
+		return geoPoint{}, false
+	}
+
+	return p, true
+}
+
+func cellOf(p geoPoint) geoCell {
+	return geoCell{
+		lat: clampLatCell(int(math.Floor(p.lat))),
+		lon: wrapLonCell(int(math.Floor(p.lon))),
+	}
+}
+
+func clampLatCell(lat int) int {
+	return max(-latCells/2, min(lat, latCells/2-1))
+}
+
+func wrapLonCell(lon int) int {
+	lon = (lon + lonCells/2) % lonCells
+	if lon < 0 {
+		lon += lonCells
+	}
+	return lon - lonCells/2
+}
+
+func (gi *geoIndex) add(port *Port) {
+	p, ok := portLocation(port)
+	if !ok {
+		return
+	}
+
+	c := cellOf(p)
+	ids, exists := gi.cells[c]
+	if !exists {
+		ids = make(map[string]struct{})
+		gi.cells[c] = ids
+	}
+	ids[port.Id] = struct{}{}
+	gi.points[port.Id] = p
+}
+
+func (gi *geoIndex) remove(port *Port) {
+	p, exists := gi.points[port.Id]
+	if !exists {
+		return
+	}
+
+	c := cellOf(p)
+	delete(gi.cells[c], port.Id)
+	if len(gi.cells[c]) == 0 {
+		delete(gi.cells, c)
+	}
+	delete(gi.points, port.Id)
+}
+
+// scanCell calls fn for every port in the cell.
+func (gi *geoIndex) scanCell(c geoCell, fn func(id string, p geoPoint)) {
+	for id := range gi.cells[c] {
+		fn(id, gi.points[id])
+	}
+}
+
+// scanBox calls fn for every port in the cells overlapping the box.
+func (gi *geoIndex) scanBox(box domain.BoundingBox, fn func(id string, p geoPoint)) {
+	minLat := clampLatCell(int(math.Floor(box.MinLat)))
+	maxLat := clampLatCell(int(math.Floor(box.MaxLat)))
+
+	minLon := int(math.Floor(box.MinLon))
+	maxLon := int(math.Floor(box.MaxLon))
+	if maxLon < minLon {
+		maxLon += lonCells
+	}
+	if maxLon-minLon >= lonCells {
+		minLon, maxLon = -lonCells/2, lonCells/2-1
+	}
+
+	for lat := minLat; lat <= maxLat; lat++ {
+		for lon := minLon; lon <= maxLon; lon++ {
+			gi.scanCell(geoCell{lat: lat, lon: wrapLonCell(lon)}, fn)
+		}
+	}
+}
+
+type geoHit struct {
+	id         string
+	distanceKm float64
+}
+
+func sortHits(hits []geoHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].distanceKm != hits[j].distanceKm {
+			return hits[i].distanceKm < hits[j].distanceKm
+		}
+		return hits[i].id < hits[j].id
+	})
+}
+
+// nearest returns the k ports closest to the point. It grows a square of
+// cells around the point until it holds k ports, then scans every cell that
+// may hold a port closer than the k-th one found so far.
+func (gi *geoIndex) nearest(lat, lon float64, k int) []geoHit {
+	if len(gi.points) == 0 || k <= 0 {
+		return nil
+	}
+
+	hits := make(map[string]float64)
+	collect := func(id string, p geoPoint) {
+		hits[id] = domain.DistanceKm(lat, lon, p.lat, p.lon)
+	}
+
+	center := cellOf(geoPoint{lat: lat, lon: lon})
+	for r := 0; len(hits) < min(k, len(gi.points)) && r <= lonCells/2; r++ {
+		for dLat := -r; dLat <= r; dLat++ {
+			cellLat := center.lat + dLat
+			if cellLat < -latCells/2 || cellLat >= latCells/2 {
+				continue
+			}
+
+			// only the border of the square is new in this round
+			step := 2 * r
+			if abs(dLat) == r || r == 0 {
+				step = 1
+			}
+			for dLon := -r; dLon <= r; dLon += step {
+				gi.scanCell(geoCell{lat: cellLat, lon: wrapLonCell(center.lon + dLon)}, collect)
+			}
+		}
+	}
+
+	radius := kthDistance(hits, k)
+	gi.scanBox(boxAround(lat, lon, radius), collect)
+
+	result := make([]geoHit, 0, len(hits))
+	for id, d := range hits {
+		result = append(result, geoHit{id: id, distanceKm: d})
+	}
+	sortHits(result)
+
+	if len(result) > k {
+		result = result[:k]
+	}
+	return result
+}
+
+func kthDistance(hits map[string]float64, k int) float64 {
+	distances := make([]float64, 0, len(hits))
+	for _, d := range hits {
+		distances = append(distances, d)
+	}
+	sort.Float64s(distances)
+
+	return distances[min(k, len(distances))-1]
+}
+
+// boxAround returns a box holding every point within radiusKm of the point.
+func boxAround(lat, lon, radiusKm float64) domain.BoundingBox {
+	dLat := radiusKm / kmPerDegree
+	box := domain.BoundingBox{
+		MinLat: lat - dLat,
+		MaxLat: lat + dLat,
+		MinLon: -180,
+		MaxLon: 180,
+	}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = max(box.MinLat, -90)
+		box.MaxLat = min(box.MaxLat, 90)
+		return box
+	}
+
+	// widest longitude span of a spherical cap, see
+	// http://janmatuschek.de/LatitudeLongitudeBoundingCoordinates
+	sinRadius := math.Sin(radiusKm / domain.EarthRadiusKm)
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if sinRadius < cosLat {
+		dLon := math.Asin(sinRadius/cosLat) * 180 / math.Pi
+		box.MinLon = lon - dLon
+		box.MaxLon = lon + dLon
+		if box.MinLon < -180 {
+			box.MinLon += 360
+		}
+		if box.MaxLon > 180 {
+			box.MaxLon -= 360
+		}
+	}
+
+	return box
+}
+
+// within returns the ports inside the box ordered by their distance from its center.
+func (gi *geoIndex) within(box domain.BoundingBox) []geoHit {
+	centerLat, centerLon := box.Center()
+
+	var result []geoHit
+	gi.scanBox(box, func(id string, p geoPoint) {
+		if box.Contains(p.lat, p.lon) {
+			result = append(result, geoHit{id: id, distanceKm: domain.DistanceKm(centerLat, centerLon, p.lat, p.lon)})
+		}
+	})
+	sortHits(result)
+
+	return result
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,98 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestPortStore_NearestPorts(t *testing.T) {
+	t.Parallel()
+
+	store := NewPortStore()
+	rnd := rand.New(rand.NewSource(1))
+	points := make(map[string][2]float64)
+	for i := 0; i < 500; i++ {
+		id := fmt.Sprintf("P%03d", i)
+		lat, lon := rnd.Float64()*180-90, rnd.Float64()*360-180
+		points[id] = [2]float64{lat, lon}
+
+		port, err := domain.NewPort(id, id, "", id, id, nil, nil, []float64{lon, lat}, "", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+	}
+
+	bruteForce := func(lat, lon float64, k int) []string {
+		ids := make([]string, 0, len(points))
+		for id := range points {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return domain.DistanceKm(lat, lon, points[ids[i]][0], points[ids[i]][1]) <
+				domain.DistanceKm(lat, lon, points[ids[j]][0], points[ids[j]][1])
+		})
+		return ids[:k]
+	}
+
+	for _, q := range [][2]float64{{0, 0}, {89.9, 10}, {-89.9, -170}, {10, 179.9}, {-30, -179.9}, {51.9, 4.1}} {
+		t.Run(fmt.Sprintf("%v", q), func(t *testing.T) {
+			ports, err := store.NearestPorts(context.Background(), q[0], q[1], 7)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(ports))
+			for i, port := range ports {
+				ids = append(ids, port.Port.Id())
+				require.Same(t, port.Port, port.Record.Port)
+				require.NotZero(t, port.Record.Version)
+				if i > 0 {
+					require.GreaterOrEqual(t, port.DistanceKm, ports[i-1].DistanceKm)
+				}
+			}
+			require.Equal(t, bruteForce(q[0], q[1], 7), ids)
+		})
+	}
+
+	t.Run("rejects invalid query", func(t *testing.T) {
+		_, err := store.NearestPorts(context.Background(), 91, 0, 1)
+		require.ErrorIs(t, err, domain.ErrInvalidQuery)
+
+		_, err = store.NearestPorts(context.Background(), 0, 0, 0)
+		require.ErrorIs(t, err, domain.ErrInvalidQuery)
+	})
+}
+
+func TestPortStore_PortsWithin(t *testing.T) {
+	t.Parallel()
+
+	store := NewPortStore()
+	for id, lonLat := range map[string][]float64{
+		"FJSUV": {178.42, -18.13},
+		"WSAPW": {-171.76, -13.83},
+		"NLRTM": {4.1, 51.9},
+		"XXXXX": nil,
+	} {
+		port, err := domain.NewPort(id, id, "", id, id, nil, nil, lonLat, "", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+	}
+
+	ports, err := store.PortsWithin(context.Background(), domain.BoundingBox{MinLat: -20, MinLon: 170, MaxLat: -10, MaxLon: -170})
+	require.NoError(t, err)
+	require.Len(t, ports, 2)
+
+	ports, err = store.PortsWithin(context.Background(), domain.BoundingBox{MinLat: 50, MinLon: 3, MaxLat: 53, MaxLon: 5})
+	require.NoError(t, err)
+	require.Len(t, ports, 1)
+	require.Equal(t, "NLRTM", ports[0].Port.Id())
+
+	require.NoError(t, store.DeletePortById(context.Background(), "NLRTM"))
+
+	ports, err = store.PortsWithin(context.Background(), domain.BoundingBox{MinLat: 50, MinLon: 3, MaxLat: 53, MaxLon: 5})
+	require.NoError(t, err)
+	require.Empty(t, ports)
+}
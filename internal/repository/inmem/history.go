@@ -0,0 +1,244 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+// Revision is one entry of the history of a port: the port as a write left
+// it, or its deletion.
+type Revision struct {
+	Id      string
+	Version uint64
+	At      time.Time
+	Deleted bool
+	// Port is nil for a deletion.
+	Port *Port `json:",omitempty"`
+}
+
+func revisionOf(port *Port) Revision {
+	return Revision{
+		Id:      port.Id,
+		Version: port.Version,
+		At:      port.UpdatedAt,
+		Port:    port,
+	}
+}
+
+func (r Revision) Copy() Revision {
+	r.Port = r.Port.Copy()
+	return r
+}
+
+func copyRevisions(revisions []Revision) []Revision {
+	copied := make([]Revision, 0, len(revisions))
+	for _, revision := range revisions {
+		copied = append(copied, revision.Copy())
+	}
+	return copied
+}
+
+// addRevision appends the revision to the history of its port and notifies
+// the change it made, before the oldest revisions beyond the limit are
+// dropped. A revision not newer than the last one is already there, as
+// happens when the log is replayed over a snapshot. Callers must hold the
+// write lock.
+func (ps *PortStore) addRevision(revision Revision) {
+	revisions := ps.history[revision.Id]
+	if n := len(revisions); n > 0 && revisions[n-1].Version >= revision.Version {
+		return
+	}
+	ps.history[revision.Id] = append(revisions, revision)
+	ps.notify(revisions, revision)
+	ps.trimHistory(ps.historyLimit, revision.Id)
+}
+
+// LimitHistory keeps at most the given number of revisions per port, zero
+// keeping them all. The histories already longer are trimmed right away.
+func (ps *PortStore) LimitHistory(revisions int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.historyLimit = revisions
+	ps.trimHistory(revisions)
+}
+
+// TrimHistory drops the oldest revisions of the given ports, or of every
+// port when none is given, beyond the given number. It lets a store that
+// rolls writes back trim once they are kept, rather than limit the history.
+func (ps *PortStore) TrimHistory(revisions int, ids ...string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.trimHistory(revisions, ids...)
+}
+
+// trimHistory is TrimHistory for callers holding the write lock.
+func (ps *PortStore) trimHistory(revisions int, ids ...string) {
+	if revisions <= 0 {
+		return
+	}
+
+	trim := func(id string) {
+		history := ps.history[id]
+		if len(history) <= revisions {
+			return
+		}
+		// copied down, so that the dropped ports can be collected
+		n := copy(history, history[len(history)-revisions:])
+		clear(history[n:])
+		ps.history[id] = history[:n]
+	}
+
+	if len(ids) == 0 {
+		for id := range ps.history {
+			trim(id)
+		}
+		return
+	}
+	for _, id := range ids {
+		trim(id)
+	}
+}
+
+// History returns a copy of the history of every port.
+func (ps *PortStore) History() map[string][]Revision {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	history := make(map[string][]Revision, len(ps.history))
+	for id, revisions := range ps.history {
+		history[id] = copyRevisions(revisions)
+	}
+
+	return history
+}
+
+// RevisionsSince returns the revisions of the given ports written after
+// sequence, in the order they were written.
+func (ps *PortStore) RevisionsSince(sequence uint64, ids ...string) []Revision {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var since []Revision
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		revisions := ps.history[id]
+		i := sort.Search(len(revisions), func(i int) bool {
+			return revisions[i].Version > sequence
+		})
+		since = append(since, copyRevisions(revisions[i:])...)
+	}
+
+	sort.SliceStable(since, func(i, j int) bool {
+		return since[i].Version < since[j].Version
+	})
+
+	return since
+}
+
+// Rollback undoes every write made after sequence: their revisions are
+// dropped and the ports go back to their last remaining revision.
+func (ps *PortStore) Rollback(sequence uint64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for id, revisions := range ps.history {
+		i := sort.Search(len(revisions), func(i int) bool {
+			return revisions[i].Version > sequence
+		})
+		if i == len(revisions) {
+			continue
+		}
+
+		revisions = revisions[:i]
+		if len(revisions) == 0 {
+			delete(ps.history, id)
+			delete(ps.deleted, id)
+			ps.remove(id)
+			continue
+		}
+
+		ps.history[id] = revisions
+		last := revisions[len(revisions)-1]
+		if !last.Deleted {
+			ps.store(last.Port)
+			continue
+		}
+
+		ps.remove(id)
+		delete(ps.deleted, id)
+		// the tombstone holds the port as it was before its deletion
+		for j := len(revisions) - 2; j >= 0; j-- {
+			if !revisions[j].Deleted {
+				tombstone := revisions[j].Port.Copy()
+				tombstone.Version = last.Version
+				deletedAt := last.At
+				tombstone.DeletedAt = &deletedAt
+				ps.deleted[id] = tombstone
+				break
+			}
+		}
+	}
+}
+
+// PortHistory returns every revision of the port, oldest first.
+func (ps *PortStore) PortHistory(_ context.Context, id string) ([]domain.PortRevision, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	revisions, exists := ps.history[id]
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+
+	history := make([]domain.PortRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		domainRevision := domain.PortRevision{
+			Version: revision.Version,
+			At:      revision.At,
+			Deleted: revision.Deleted,
+		}
+		if !revision.Deleted {
+			domainPort, err := portStoreToDomain(revision.Port)
+			if err != nil {
+				return nil, fmt.Errorf("portStoreToDomain failed: %w", err)
+			}
+			domainRevision.Port = domainPort
+		}
+		history = append(history, domainRevision)
+	}
+
+	return history, nil
+}
+
+// GetPortAsOf returns the port as it was at the given instant. A port that
+// did not exist yet, or was deleted at that instant, is not found.
+func (ps *PortStore) GetPortAsOf(_ context.Context, id string, at time.Time) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	revisions := ps.history[id]
+	i := sort.Search(len(revisions), func(i int) bool {
+		return revisions[i].At.After(at)
+	})
+	if i == 0 || revisions[i-1].Deleted {
+		return nil, domain.ErrNotFound
+	}
+
+	record, err := portStoreToRecord(revisions[i-1].Port)
+	if err != nil {
+		return nil, fmt.Errorf("portStoreToRecord failed: %w", err)
+	}
+
+	return record, nil
+}
@@ -0,0 +1,109 @@
+package inmem
+
+import (
+	"sort"
+	"strings"
+)
+
+// multiIndex maps a normalised key to the ids of the ports having it.
+type multiIndex map[string]map[string]struct{}
+
+func (mi multiIndex) add(key, id string) {
+	if key == "" {
+		return
+	}
+
+	ids, exists := mi[key]
+	if !exists {
+		ids = make(map[string]struct{})
+		mi[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (mi multiIndex) remove(key, id string) {
+	ids, exists := mi[key]
+	if !exists {
+		return
+	}
+
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(mi, key)
+	}
+}
+
+// lookup returns the ids stored under key in ascending order.
+func (mi multiIndex) lookup(key string) []string {
+	ids := make([]string, 0, len(mi[key]))
+	for id := range mi[key] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// portIndex holds the secondary indexes of the store. It is guarded by the
+// store lock and must be updated on every write to PortStore.data.
+type portIndex struct {
+	byCountry multiIndex
+	byUnloc   multiIndex
+	byCode    multiIndex
+	byAlias   multiIndex
+	geo       *geoIndex
+	search    *searchIndex
+	prefix    *prefixIndex
+}
+
+func newPortIndex() *portIndex {
+	return &portIndex{
+		byCountry: make(multiIndex),
+		byUnloc:   make(multiIndex),
+		byCode:    make(multiIndex),
+		byAlias:   make(multiIndex),
+		geo:       newGeoIndex(),
+		search:    newSearchIndex(),
+		prefix:    newPrefixIndex(),
+	}
+}
+
+func (pi *portIndex) add(port *Port) {
+	pi.byCountry.add(countryKey(port.Country), port.Id)
+	pi.byCode.add(port.Code, port.Id)
+	for _, unloc := range port.Unlocs {
+		pi.byUnloc.add(unlocKey(unloc), port.Id)
+	}
+	for _, alias := range port.Alias {
+		pi.byAlias.add(aliasKey(alias), port.Id)
+	}
+	pi.geo.add(port)
+	pi.search.add(port)
+	pi.prefix.add(port)
+}
+
+func (pi *portIndex) remove(port *Port) {
+	pi.byCountry.remove(countryKey(port.Country), port.Id)
+	pi.byCode.remove(port.Code, port.Id)
+	for _, unloc := range port.Unlocs {
+		pi.byUnloc.remove(unlocKey(unloc), port.Id)
+	}
+	for _, alias := range port.Alias {
+		pi.byAlias.remove(aliasKey(alias), port.Id)
+	}
+	pi.geo.remove(port)
+	pi.search.remove(port)
+	pi.prefix.remove(port)
+}
+
+func countryKey(country string) string {
+	return strings.ToLower(strings.TrimSpace(country))
+}
+
+func unlocKey(unloc string) string {
+	return strings.ToUpper(strings.TrimSpace(unloc))
+}
+
+func aliasKey(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
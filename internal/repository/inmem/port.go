@@ -19,8 +19,18 @@ type Port struct {
 	Province    string
 	Timezone    string
 	Unlocs      []string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// Version is the store sequence number of the last write of the port.
+	Version   uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// DeletedAt is set on tombstones, nil on live ports. Logs written by
+	// older versions hold the zero time for live ports, which counts as nil.
+	DeletedAt *time.Time `json:",omitempty"`
+}
+
+// deleted tells whether the port is a tombstone.
+func (p *Port) deleted() bool {
+	return p.DeletedAt != nil && !p.DeletedAt.IsZero()
 }
 
 func (p *Port) Copy() *Port {
@@ -28,6 +38,12 @@ func (p *Port) Copy() *Port {
 		return nil
 	}
 
+	var deletedAt *time.Time
+	if p.deleted() {
+		at := *p.DeletedAt
+		deletedAt = &at
+	}
+
 	return &Port{
 		Id:          p.Id,
 		Name:        p.Name,
@@ -39,9 +55,11 @@ func (p *Port) Copy() *Port {
 		Coordinates: append([]float64(nil), p.Coordinates...),
 		Province:    p.Province,
 		Timezone:    p.Timezone,
-		Unlocs:      p.Unlocs,
+		Unlocs:      append([]string(nil), p.Unlocs...),
+		Version:     p.Version,
 		CreatedAt:   p.CreatedAt,
 		UpdatedAt:   p.UpdatedAt,
+		DeletedAt:   deletedAt,
 	}
 }
 
@@ -50,19 +68,42 @@ func portStoreToDomain(port *Port) (*domain.Port, error) {
 		return nil, fmt.Errorf("store port is nil")
 	}
 
-	return domain.NewPort(
-		port.Id,
-		port.Name,
-		port.Code,
-		port.City,
-		port.Country,
-		append([]string(nil), port.Alias...),
-		append([]string(nil), port.Regions...),
-		append([]float64(nil), port.Coordinates...),
-		port.Province,
-		port.Timezone,
-		append([]string(nil), port.Unlocs...),
-	)
+	domainPort, err := domain.NewPortBuilder().
+		Id(port.Id).
+		Name(port.Name).
+		Code(port.Code).
+		City(port.City).
+		Country(port.Country).
+		Alias(append([]string(nil), port.Alias...)...).
+		Regions(append([]string(nil), port.Regions...)...).
+		Coordinates(append([]float64(nil), port.Coordinates...)).
+		Province(port.Province).
+		Timezone(port.Timezone).
+		Unlocs(append([]string(nil), port.Unlocs...)...).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if port.deleted() {
+		domainPort.SetDeletedAt(*port.DeletedAt)
+	}
+
+	return domainPort, nil
+}
+
+func portStoreToRecord(port *Port) (*domain.PortRecord, error) {
+	domainPort, err := portStoreToDomain(port)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PortRecord{
+		Port:      domainPort,
+		Version:   port.Version,
+		CreatedAt: port.CreatedAt,
+		UpdatedAt: port.UpdatedAt,
+	}, nil
 }
 
 func portDomainToStore(p *domain.Port) *Port {
@@ -3,6 +3,7 @@ package inmem
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,13 +11,31 @@ import (
 )
 
 type PortStore struct {
-	data map[string]*Port
-	mu   sync.RWMutex
+	data  map[string]*Port
+	index *portIndex
+	// deleted holds the tombstones of deleted ports until they are purged.
+	// They are kept out of the indexes, reads and counts.
+	deleted map[string]*Port
+	// history holds the revisions of every port stored, deleted ones
+	// included, up to historyLimit of them per port.
+	history map[string][]Revision
+	// historyLimit is how many revisions of a port its history keeps, the
+	// oldest being dropped first. Zero keeps them all.
+	historyLimit int
+	// sequence is the last version given to a port. It only ever grows, so
+	// a version is never handed out twice, not even after a delete.
+	sequence uint64
+	// onChange are called with every change made, see OnChange.
+	onChange []func(change domain.PortChange)
+	mu       sync.RWMutex
 }
 
 func NewPortStore() *PortStore {
 	return &PortStore{
-		data: make(map[string]*Port),
+		data:    make(map[string]*Port),
+		index:   newPortIndex(),
+		deleted: make(map[string]*Port),
+		history: make(map[string][]Revision),
 	}
 }
 
@@ -37,6 +56,24 @@ func (ps *PortStore) GetPort(_ context.Context, id string) (*domain.Port, error)
 	return domainPort, nil
 }
 
+// GetPortRecord returns the port with its version and timestamps.
+func (ps *PortStore) GetPortRecord(_ context.Context, id string) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	storePort, exists := ps.data[id]
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+
+	record, err := portStoreToRecord(storePort)
+	if err != nil {
+		return nil, fmt.Errorf("portStoreToRecord failed: %w", err)
+	}
+
+	return record, nil
+}
+
 func (ps *PortStore) CountPorts(_ context.Context) (int, error) {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
@@ -68,6 +105,31 @@ func (ps *PortStore) CreateOrUpdatePort(ctx context.Context, port *domain.Port)
 	}
 }
 
+// CreatePort stores a new port. It fails with domain.ErrAlreadyExists when
+// a live port has the same id.
+func (ps *PortStore) CreatePort(ctx context.Context, port *domain.Port) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if port == nil {
+		return domain.ErrNil
+	}
+
+	storePort := portDomainToStore(port)
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, exists := ps.data[storePort.Id]; exists {
+		return fmt.Errorf("port %s: %w", storePort.Id, domain.ErrAlreadyExists)
+	}
+
+	return ps.createPort(ctx, storePort)
+}
+
 func (ps *PortStore) createPort(ctx context.Context, storePort *Port) error {
 	select {
 	case <-ctx.Done():
@@ -81,8 +143,10 @@ func (ps *PortStore) createPort(ctx context.Context, storePort *Port) error {
 
 	storePort.CreatedAt = time.Now()
 	storePort.UpdatedAt = time.Now()
+	storePort.Version = ps.nextVersion()
 
-	ps.data[storePort.Id] = storePort
+	ps.store(storePort)
+	ps.addRevision(revisionOf(storePort))
 
 	return nil
 }
@@ -99,12 +163,12 @@ func (ps *PortStore) updatePort(ctx context.Context, port *Port) error {
 		return domain.ErrNil
 	}
 
-	port, exist := ps.data[port.Id]
+	storedPort, exist := ps.data[port.Id]
 	if !exist {
 		return domain.ErrNotFound
 	}
 
-	storePortCopy := port.Copy()
+	storePortCopy := storedPort.Copy()
 
 	storePortCopy.Name = port.Name
 	storePortCopy.Code = port.Code
@@ -118,12 +182,80 @@ func (ps *PortStore) updatePort(ctx context.Context, port *Port) error {
 	storePortCopy.Unlocs = append([]string(nil), port.Unlocs...)
 
 	storePortCopy.UpdatedAt = time.Now()
+	storePortCopy.Version = ps.nextVersion()
+
+	ps.store(storePortCopy)
+	ps.addRevision(revisionOf(storePortCopy))
+
+	return nil
+}
+
+// UpdatePortIfVersion updates an existing port, provided it is still at the
+// given version. Otherwise it fails with domain.ErrVersionMismatch.
+func (ps *PortStore) UpdatePortIfVersion(ctx context.Context, port *domain.Port, version uint64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
 
-	ps.data[port.Id] = storePortCopy
+	if port == nil {
+		return domain.ErrNil
+	}
+
+	storePort := portDomainToStore(port)
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	err := ps.checkVersion(storePort.Id, version)
+	if err != nil {
+		return err
+	}
+
+	return ps.updatePort(ctx, storePort)
+}
+
+// DeletePortIfVersion deletes the port, provided it is still at the given
+// version. Otherwise it fails with domain.ErrVersionMismatch.
+func (ps *PortStore) DeletePortIfVersion(ctx context.Context, id string, version uint64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	err := ps.checkVersion(id, version)
+	if err != nil {
+		return err
+	}
 
+	ps.tombstone(id, ps.nextVersion(), time.Now())
 	return nil
 }
 
+// checkVersion makes sure the port is stored at the given version. Callers must hold the lock.
+func (ps *PortStore) checkVersion(id string, version uint64) error {
+	stored, exists := ps.data[id]
+	if !exists {
+		return domain.ErrNotFound
+	}
+	if stored.Version != version {
+		return fmt.Errorf("port %s is at version %d, not %d: %w", id, stored.Version, version, domain.ErrVersionMismatch)
+	}
+
+	return nil
+}
+
+// nextVersion hands out the next version. Callers must hold the write lock.
+func (ps *PortStore) nextVersion() uint64 {
+	ps.sequence++
+	return ps.sequence
+}
+
 func (ps *PortStore) DeletePortById(ctx context.Context, id string) error {
 	// Check for context cancellation
 	select {
@@ -140,7 +272,7 @@ func (ps *PortStore) DeletePortById(ctx context.Context, id string) error {
 	if _, exists := ps.data[id]; !exists {
 		return domain.ErrNotFound
 	}
-	delete(ps.data, id)
+	ps.tombstone(id, ps.nextVersion(), time.Now())
 	return nil
 }
 
@@ -156,8 +288,634 @@ func (ps *PortStore) DeleteAllPorts(ctx context.Context) error {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
-	// Reinitialize the map to clear all ports
-	ps.data = make(map[string]*Port)
+	ps.removeAll(ps.nextVersion(), time.Now())
+
+	return nil
+}
+
+// removeAll turns every port into a tombstone, recording their deletion
+// under the given version. Callers must hold the write lock.
+func (ps *PortStore) removeAll(version uint64, at time.Time) {
+	for id := range ps.data {
+		ps.tombstone(id, version, at)
+	}
+}
+
+// tombstone deletes the port, keeping it as a tombstone and recording its
+// deletion under the given version. Callers must hold the write lock.
+func (ps *PortStore) tombstone(id string, version uint64, at time.Time) {
+	port, exists := ps.data[id]
+	if !exists {
+		return
+	}
+
+	ps.remove(id)
+
+	tombstone := port.Copy()
+	tombstone.Version = version
+	tombstone.DeletedAt = &at
+	ps.deleted[id] = tombstone
+	ps.addRevision(Revision{Id: id, Version: version, At: at, Deleted: true})
+}
+
+// RestorePort brings a deleted port back from its tombstone. It fails with
+// domain.ErrAlreadyExists when the port is live and domain.ErrNotFound when
+// there is no tombstone, such as after a purge.
+func (ps *PortStore) RestorePort(ctx context.Context, id string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, exists := ps.data[id]; exists {
+		return fmt.Errorf("port %s: %w", id, domain.ErrAlreadyExists)
+	}
+	tombstone, exists := ps.deleted[id]
+	if !exists {
+		return domain.ErrNotFound
+	}
+
+	port := tombstone.Copy()
+	port.DeletedAt = nil
+	port.UpdatedAt = time.Now()
+	port.Version = ps.nextVersion()
+
+	ps.store(port)
+	ps.addRevision(revisionOf(port))
+
+	return nil
+}
+
+// GetDeletedPort returns the tombstone of a deleted port.
+func (ps *PortStore) GetDeletedPort(_ context.Context, id string) (*domain.PortRecord, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	tombstone, exists := ps.deleted[id]
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+
+	record, err := portStoreToRecord(tombstone)
+	if err != nil {
+		return nil, fmt.Errorf("portStoreToRecord failed: %w", err)
+	}
+
+	return record, nil
+}
+
+// PurgeDeletedPorts drops the tombstones of ports deleted before the given
+// time for good, with their history, and returns their ids. Tombstones are
+// picked and dropped under the same lock, so that a port restored and
+// deleted again meanwhile keeps its fresh tombstone.
+func (ps *PortStore) PurgeDeletedPorts(ctx context.Context, before time.Time) ([]string, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ids := ps.deletedBefore(before)
+	for _, id := range ids {
+		ps.purge(id)
+	}
+
+	return ids, nil
+}
+
+// DeletedBefore returns the ids of the tombstones of ports deleted before
+// the given time, ordered by id.
+func (ps *PortStore) DeletedBefore(before time.Time) []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.deletedBefore(before)
+}
+
+// deletedBefore is DeletedBefore for callers holding the lock.
+func (ps *PortStore) deletedBefore(before time.Time) []string {
+	ids := make([]string, 0)
+	for id, tombstone := range ps.deleted {
+		if tombstone.deleted() && tombstone.DeletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Purge drops the tombstones of the given ports, and their history.
+func (ps *PortStore) Purge(ids ...string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, id := range ids {
+		ps.purge(id)
+	}
+}
+
+// purge drops the tombstone of the port and its history, so that nothing of
+// it is left. Callers must hold the write lock.
+func (ps *PortStore) purge(id string) {
+	if _, exists := ps.deleted[id]; !exists {
+		return
+	}
+
+	delete(ps.deleted, id)
+	delete(ps.history, id)
+}
+
+// Snapshot returns a copy of every stored port, tombstones included,
+// ordered by id.
+func (ps *PortStore) Snapshot() []*Port {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	ports := make([]*Port, 0, len(ps.data)+len(ps.deleted))
+	for _, port := range ps.data {
+		ports = append(ports, port.Copy())
+	}
+	for _, tombstone := range ps.deleted {
+		ports = append(ports, tombstone.Copy())
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Id < ports[j].Id
+	})
+
+	return ports
+}
+
+// Restore replaces the whole content of the store with the given ports,
+// tombstones among them, and history, keeping versions and timestamps as
+// they are. Ports without any history start it with their current state.
+func (ps *PortStore) Restore(ports []*Port, history map[string][]Revision) {
+	data := make(map[string]*Port, len(ports))
+	deleted := make(map[string]*Port)
+	index := newPortIndex()
+	for _, port := range ports {
+		if port == nil {
+			continue
+		}
+		if port.deleted() {
+			deleted[port.Id] = port.Copy()
+			continue
+		}
+		if previous, exists := data[port.Id]; exists {
+			index.remove(previous)
+		}
+		data[port.Id] = port.Copy()
+		index.add(data[port.Id])
+	}
+
+	revisions := make(map[string][]Revision, len(history))
+	for id, portRevisions := range history {
+		// a deleted port without a tombstone was purged, snapshots from
+		// before purges dropped the history keep it
+		last := len(portRevisions) - 1
+		if last >= 0 && portRevisions[last].Deleted && deleted[id] == nil {
+			continue
+		}
+		revisions[id] = copyRevisions(portRevisions)
+	}
+	for id, port := range data {
+		if len(revisions[id]) == 0 {
+			revisions[id] = []Revision{revisionOf(port)}
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.data = data
+	ps.deleted = deleted
+	ps.index = index
+	ps.history = revisions
+	for _, portRevisions := range revisions {
+		if len(portRevisions) > 0 {
+			ps.sequence = max(ps.sequence, portRevisions[len(portRevisions)-1].Version)
+		}
+	}
+	for _, port := range ports {
+		if port != nil {
+			ps.sequence = max(ps.sequence, port.Version)
+		}
+	}
+}
+
+// Put stores the port as is, keeping its version and timestamps, and adds
+// it to the history unless a later revision is there already. The sequence
+// moves past the version, so later writes get higher ones.
+func (ps *PortStore) Put(port *Port) {
+	if port == nil {
+		return
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.sequence = max(ps.sequence, port.Version)
+	port = port.Copy()
+	ps.store(port)
+	ps.addRevision(revisionOf(port))
+}
+
+// Remove turns the port into a tombstone as a deletion of the given version
+// made at the given time, the way Put stores a port.
+func (ps *PortStore) Remove(id string, version uint64, at time.Time) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.sequence = max(ps.sequence, version)
+	ps.tombstone(id, version, at)
+}
+
+// RemoveAll deletes every port the way Remove deletes one.
+func (ps *PortStore) RemoveAll(version uint64, at time.Time) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.sequence = max(ps.sequence, version)
+	ps.removeAll(version, at)
+}
+
+// Sequence returns the last version given to a port.
+func (ps *PortStore) Sequence() uint64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.sequence
+}
+
+// AdvanceSequence moves the sequence up to at least sequence. It is used to
+// carry the sequence over a restart, past versions of ports deleted since.
+func (ps *PortStore) AdvanceSequence(sequence uint64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.sequence = max(ps.sequence, sequence)
+}
+
+// store saves the port, replacing any tombstone, and keeps the indexes in
+// line. Callers must hold the write lock.
+func (ps *PortStore) store(port *Port) {
+	delete(ps.deleted, port.Id)
+	if previous, exists := ps.data[port.Id]; exists {
+		ps.index.remove(previous)
+	}
+	ps.data[port.Id] = port
+	ps.index.add(port)
+}
+
+// remove deletes the port and drops it from the indexes. Callers must hold the write lock.
+func (ps *PortStore) remove(id string) {
+	port, exists := ps.data[id]
+	if !exists {
+		return
+	}
+	ps.index.remove(port)
+	delete(ps.data, id)
+}
+
+func (ps *PortStore) ListPorts(ctx context.Context, query domain.PortQuery) (*domain.PortPage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *domain.PortCursor
+	if query.Cursor != "" {
+		c, err := domain.DecodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &c
+	}
+
+	// stored ports are never modified in place, so they can be read after unlocking
+	ps.mu.RLock()
+	matched := make([]*Port, 0)
+	ps.eachCandidate(query.Filter, func(port *Port) {
+		if matchesFilter(port, query.Filter) {
+			matched = append(matched, port)
+		}
+	})
+	if query.IncludeDeleted {
+		for _, tombstone := range ps.deleted {
+			if matchesFilter(tombstone, query.Filter) {
+				matched = append(matched, tombstone)
+			}
+		}
+	}
+	ps.mu.RUnlock()
+
+	sortPorts(matched, query.Sort)
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return comparePort(matched[i], query.Sort, cursor.Key, cursor.Id) > 0
+		})
+	}
+
+	end := min(start+query.Limit, len(matched))
+
+	page := &domain.PortPage{
+		Ports:   make([]*domain.Port, 0, end-start),
+		Records: make([]*domain.PortRecord, 0, end-start),
+	}
+	for _, storePort := range matched[start:end] {
+		record, err := portStoreToRecord(storePort)
+		if err != nil {
+			return nil, fmt.Errorf("portStoreToRecord failed: %w", err)
+		}
+		page.Ports = append(page.Ports, record.Port)
+		page.Records = append(page.Records, record)
+	}
+
+	if end < len(matched) {
+		last := matched[end-1]
+		page.NextCursor = domain.EncodeCursor(domain.PortCursor{
+			Sort: query.Sort,
+			Key:  sortKey(last, query.Sort),
+			Id:   last.Id,
+		})
+	}
+
+	return page, nil
+}
+
+// eachCandidate calls fn for every port that may match the filter, narrowing
+// the scan down with an index when the filter allows it. Callers must hold the lock.
+func (ps *PortStore) eachCandidate(filter domain.PortFilter, fn func(port *Port)) {
+	var ids []string
+	switch {
+	case filter.Unloc != "":
+		ids = ps.index.byUnloc.lookup(unlocKey(filter.Unloc))
+	case filter.Country != "":
+		ids = ps.index.byCountry.lookup(countryKey(filter.Country))
+	default:
+		for _, port := range ps.data {
+			fn(port)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		fn(ps.data[id])
+	}
+}
+
+// GetPortByUnloc returns the port having the given UN/LOCODE. When several
+// ports share it, the one with the lowest id wins.
+func (ps *PortStore) GetPortByUnloc(_ context.Context, unloc string) (*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	ids := ps.index.byUnloc.lookup(unlocKey(unloc))
+	if len(ids) == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	domainPort, err := portStoreToDomain(ps.data[ids[0]])
+	if err != nil {
+		return nil, fmt.Errorf("portStoreToDomain failed: %w", err)
+	}
+
+	return domainPort, nil
+}
+
+// FindPortsByCode returns the ports with the given code ordered by id.
+func (ps *PortStore) FindPortsByCode(_ context.Context, code string) ([]*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.portsByIds(ps.index.byCode.lookup(code))
+}
+
+// FindPortsByAlias returns the ports known under the given alias ordered by id.
+// Aliases are compared case-insensitively.
+func (ps *PortStore) FindPortsByAlias(_ context.Context, alias string) ([]*domain.Port, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.portsByIds(ps.index.byAlias.lookup(aliasKey(alias)))
+}
+
+func (ps *PortStore) portsByIds(ids []string) ([]*domain.Port, error) {
+	ports := make([]*domain.Port, 0, len(ids))
+	for _, id := range ids {
+		domainPort, err := portStoreToDomain(ps.data[id])
+		if err != nil {
+			return nil, fmt.Errorf("portStoreToDomain failed: %w", err)
+		}
+		ports = append(ports, domainPort)
+	}
+
+	return ports, nil
+}
+
+// NearestPorts returns up to k ports closest to the point, nearest first.
+func (ps *PortStore) NearestPorts(_ context.Context, lat, lon float64, k int) ([]domain.NearbyPort, error) {
+	err := domain.ValidateLatLon(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	if k <= 0 || k > domain.MaxNearest {
+		return nil, fmt.Errorf("%w: k must be between 1 and %d", domain.ErrInvalidQuery, domain.MaxNearest)
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.nearbyPorts(ps.index.geo.nearest(lat, lon, k))
+}
+
+// PortsWithin returns the ports inside the box ordered by their distance from its center.
+func (ps *PortStore) PortsWithin(_ context.Context, box domain.BoundingBox) ([]domain.NearbyPort, error) {
+	err := box.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.nearbyPorts(ps.index.geo.within(box))
+}
+
+// SearchPorts returns the ports whose name, alias, city or province match
+// the text, most relevant first.
+func (ps *PortStore) SearchPorts(_ context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	hits := ps.index.search.search(query.Text, query.Limit)
+	results := make([]domain.SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		domainPort, err := portStoreToDomain(ps.data[hit.id])
+		if err != nil {
+			return nil, fmt.Errorf("portStoreToDomain failed: %w", err)
+		}
+		results = append(results, domain.SearchHit{Port: domainPort, Score: hit.score, Field: hit.field})
+	}
+
+	return results, nil
+}
+
+// AutocompletePorts returns the ports whose name or unloc starts with the
+// prefix, in the order of the matched values.
+func (ps *PortStore) AutocompletePorts(_ context.Context, query domain.AutocompleteQuery) ([]domain.Suggestion, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	hits := ps.index.prefix.lookup(query.Prefix, query.Limit)
+	suggestions := make([]domain.Suggestion, 0, len(hits))
+	for _, hit := range hits {
+		suggestions = append(suggestions, domain.Suggestion{
+			Id:    hit.id,
+			Name:  ps.data[hit.id].Name,
+			Field: hit.field,
+			Value: hit.value,
+		})
+	}
+
+	return suggestions, nil
+}
+
+func (ps *PortStore) nearbyPorts(hits []geoHit) ([]domain.NearbyPort, error) {
+	ports := make([]domain.NearbyPort, 0, len(hits))
+	for _, hit := range hits {
+		record, err := portStoreToRecord(ps.data[hit.id])
+		if err != nil {
+			return nil, fmt.Errorf("portStoreToRecord failed: %w", err)
+		}
+		ports = append(ports, domain.NearbyPort{Port: record.Port, Record: record, DistanceKm: hit.distanceKm})
+	}
+
+	return ports, nil
+}
+
+// ApplyBatch applies every write of the batch under a single lock. When any
+// of them cannot be applied, none is and the store stays unchanged.
+func (ps *PortStore) ApplyBatch(ctx context.Context, batch *domain.PortBatch) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	if batch == nil {
+		return domain.ErrNil
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	// check the whole batch up front, so that applying it cannot fail half way
+	exists := make(map[string]bool)
+	for _, op := range batch.Ops() {
+		_, stored := ps.data[op.Id]
+		present, staged := exists[op.Id]
+		if !staged {
+			present = stored
+		}
+
+		switch op.Kind {
+		case domain.BatchUpsert:
+			exists[op.Id] = true
+		case domain.BatchInsert:
+			if present {
+				return fmt.Errorf("batch insert of port %s: %w", op.Id, domain.ErrAlreadyExists)
+			}
+			exists[op.Id] = true
+		case domain.BatchUpdate:
+			if !present {
+				return fmt.Errorf("batch update of port %s: %w", op.Id, domain.ErrNotFound)
+			}
+		case domain.BatchDelete:
+			if !present {
+				return fmt.Errorf("batch delete of port %s: %w", op.Id, domain.ErrNotFound)
+			}
+			exists[op.Id] = false
+		}
+	}
+
+	// the batch has been checked, a cancellation must not interrupt it any more
+	ctx = context.WithoutCancel(ctx)
+	for _, op := range batch.Ops() {
+		var err error
+		switch op.Kind {
+		case domain.BatchUpsert, domain.BatchInsert, domain.BatchUpdate:
+			storePort := portDomainToStore(op.Port)
+			if _, stored := ps.data[op.Id]; stored {
+				err = ps.updatePort(ctx, storePort)
+			} else {
+				err = ps.createPort(ctx, storePort)
+			}
+		case domain.BatchDelete:
+			ps.tombstone(op.Id, ps.nextVersion(), time.Now())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ForEachPort calls fn for every port ordered by id. The ports come from a
+// consistent snapshot of the store taken when the call starts, and fn runs
+// without holding the store lock. Iteration stops at the first error of fn.
+func (ps *PortStore) ForEachPort(ctx context.Context, fn func(port *domain.Port) error) error {
+	// stored ports are never modified in place, so they can be read after unlocking
+	ps.mu.RLock()
+	ports := make([]*Port, 0, len(ps.data))
+	for _, port := range ps.data {
+		ports = append(ports, port)
+	}
+	ps.mu.RUnlock()
+
+	sortPorts(ports, domain.SortById)
+
+	for _, storePort := range ports {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		domainPort, err := portStoreToDomain(storePort)
+		if err != nil {
+			return fmt.Errorf("portStoreToDomain failed: %w", err)
+		}
+
+		err = fn(domainPort)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -3,6 +3,7 @@ package inmem
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -145,3 +146,555 @@ func createRandomPortAndVerify(t *testing.T, store *PortStore, port *domain.Port
 	require.NoError(t, err)
 	require.Equal(t, storedPort, port)
 }
+
+func TestPortStore_ListPorts(t *testing.T) {
+	t.Parallel()
+
+	store := NewPortStore()
+	for _, p := range []struct{ id, name, country, unloc string }{
+		{"AEAJM", "Ajman", "United Arab Emirates", "AEAJM"},
+		{"AEAUH", "Abu Dhabi", "United Arab Emirates", "AEAUH"},
+		{"AEDXB", "Dubai", "United Arab Emirates", "AEDXB"},
+		{"NLRTM", "Rotterdam", "Netherlands", "NLRTM"},
+	} {
+		port, err := domain.NewPort(p.id, p.name, "", p.name, p.country, nil, nil, nil, "", "", []string{p.unloc})
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+	}
+
+	listIds := func(t *testing.T, query domain.PortQuery) []string {
+		t.Helper()
+		var ids []string
+		for {
+			page, err := store.ListPorts(context.Background(), query)
+			require.NoError(t, err)
+			require.Len(t, page.Records, len(page.Ports))
+			for i, port := range page.Ports {
+				ids = append(ids, port.Id())
+				require.Same(t, port, page.Records[i].Port)
+				require.NotZero(t, page.Records[i].Version)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	t.Run("pages by id", func(t *testing.T) {
+		t.Parallel()
+
+		ids := listIds(t, domain.PortQuery{Limit: 3})
+		require.Equal(t, []string{"AEAJM", "AEAUH", "AEDXB", "NLRTM"}, ids)
+	})
+
+	t.Run("pages by name", func(t *testing.T) {
+		t.Parallel()
+
+		ids := listIds(t, domain.PortQuery{Sort: domain.SortByName, Limit: 1})
+		require.Equal(t, []string{"AEAUH", "AEAJM", "AEDXB", "NLRTM"}, ids)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		t.Parallel()
+
+		ids := listIds(t, domain.PortQuery{Filter: domain.PortFilter{Country: "united arab emirates"}})
+		require.Equal(t, []string{"AEAJM", "AEAUH", "AEDXB"}, ids)
+
+		ids = listIds(t, domain.PortQuery{Filter: domain.PortFilter{Unloc: "nlrtm"}})
+		require.Equal(t, []string{"NLRTM"}, ids)
+	})
+
+	t.Run("rejects cursor of another sort", func(t *testing.T) {
+		t.Parallel()
+
+		page, err := store.ListPorts(context.Background(), domain.PortQuery{Limit: 1})
+		require.NoError(t, err)
+
+		_, err = store.ListPorts(context.Background(), domain.PortQuery{Sort: domain.SortByName, Cursor: page.NextCursor})
+		require.ErrorIs(t, err, domain.ErrInvalidQuery)
+	})
+}
+
+func TestPortStore_Indexes(t *testing.T) {
+	t.Parallel()
+
+	newPort := func(t *testing.T, id, code string, alias, unlocs []string) *domain.Port {
+		t.Helper()
+		port, err := domain.NewPort(id, id, code, id, "Netherlands", alias, nil, nil, "", "", unlocs)
+		require.NoError(t, err)
+		return port
+	}
+
+	t.Run("follows create, update and delete", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+		ctx := context.Background()
+
+		require.NoError(t, store.CreateOrUpdatePort(ctx, newPort(t, "NLRTM", "42", []string{"Rotterdam Europoort"}, []string{"NLRTM"})))
+
+		port, err := store.GetPortByUnloc(ctx, "nlrtm")
+		require.NoError(t, err)
+		require.Equal(t, "NLRTM", port.Id())
+
+		ports, err := store.FindPortsByCode(ctx, "42")
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+
+		ports, err = store.FindPortsByAlias(ctx, "rotterdam europoort")
+		require.NoError(t, err)
+		require.Len(t, ports, 1)
+
+		require.NoError(t, store.CreateOrUpdatePort(ctx, newPort(t, "NLRTM", "43", nil, []string{"NLEUR"})))
+
+		_, err = store.GetPortByUnloc(ctx, "NLRTM")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		port, err = store.GetPortByUnloc(ctx, "NLEUR")
+		require.NoError(t, err)
+		require.Equal(t, "NLRTM", port.Id())
+
+		ports, err = store.FindPortsByCode(ctx, "42")
+		require.NoError(t, err)
+		require.Empty(t, ports)
+		ports, err = store.FindPortsByAlias(ctx, "Rotterdam Europoort")
+		require.NoError(t, err)
+		require.Empty(t, ports)
+
+		require.NoError(t, store.DeletePortById(ctx, "NLRTM"))
+
+		_, err = store.GetPortByUnloc(ctx, "NLEUR")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		ports, err = store.FindPortsByCode(ctx, "43")
+		require.NoError(t, err)
+		require.Empty(t, ports)
+	})
+
+	t.Run("cleared by delete all", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+		ctx := context.Background()
+
+		require.NoError(t, store.CreateOrUpdatePort(ctx, newPort(t, "NLAMS", "1", nil, []string{"NLAMS"})))
+		require.NoError(t, store.CreateOrUpdatePort(ctx, newPort(t, "NLRTM", "1", nil, []string{"NLRTM"})))
+
+		ports, err := store.FindPortsByCode(ctx, "1")
+		require.NoError(t, err)
+		require.Len(t, ports, 2)
+
+		require.NoError(t, store.DeleteAllPorts(ctx))
+
+		ports, err = store.FindPortsByCode(ctx, "1")
+		require.NoError(t, err)
+		require.Empty(t, ports)
+
+		page, err := store.ListPorts(ctx, domain.PortQuery{Filter: domain.PortFilter{Country: "Netherlands"}})
+		require.NoError(t, err)
+		require.Empty(t, page.Ports)
+	})
+}
+
+func TestPortStore_ApplyBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applies every write", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		existing := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, existing)
+
+		created := newRandomDomainPort(t)
+		batch := domain.NewPortBatch()
+		require.NoError(t, batch.Upsert(created))
+		batch.Delete(existing.Id())
+
+		require.NoError(t, store.ApplyBatch(context.Background(), batch))
+
+		port, err := store.GetPort(context.Background(), created.Id())
+		require.NoError(t, err)
+		require.Equal(t, created, port)
+
+		_, err = store.GetPort(context.Background(), existing.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("applies nothing when a write fails", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		batch := domain.NewPortBatch()
+		require.NoError(t, batch.Upsert(newRandomDomainPort(t)))
+		batch.Delete("unknown")
+
+		err := store.ApplyBatch(context.Background(), batch)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		count, err := store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+	t.Run("insert and update check the port", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		existing := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, existing)
+
+		batch := domain.NewPortBatch()
+		require.NoError(t, batch.Insert(newRandomDomainPort(t)))
+		require.NoError(t, batch.Insert(existing))
+		err := store.ApplyBatch(context.Background(), batch)
+		require.ErrorIs(t, err, domain.ErrAlreadyExists)
+
+		batch = domain.NewPortBatch()
+		require.NoError(t, batch.Update(existing))
+		require.NoError(t, batch.Update(newRandomDomainPort(t)))
+		err = store.ApplyBatch(context.Background(), batch)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		count, err := store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		created := newRandomDomainPort(t)
+		batch = domain.NewPortBatch()
+		require.NoError(t, batch.Insert(created))
+		require.NoError(t, batch.Update(created))
+		require.NoError(t, batch.Update(existing))
+		require.NoError(t, store.ApplyBatch(context.Background(), batch))
+
+		count, err = store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+}
+
+func TestPortStore_Versions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("every write gets a higher version", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+
+		created, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Equal(t, port, created.Port)
+
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+
+		updated, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Greater(t, updated.Version, created.Version)
+		require.Equal(t, created.CreatedAt, updated.CreatedAt)
+	})
+
+	t.Run("compare and set update", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+
+		record, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+
+		require.NoError(t, port.SetName("first"))
+		require.NoError(t, store.UpdatePortIfVersion(context.Background(), port, record.Version))
+
+		// a second writer still holding the old version loses
+		require.NoError(t, port.SetName("second"))
+		err = store.UpdatePortIfVersion(context.Background(), port, record.Version)
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		stored, err := store.GetPort(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Equal(t, "first", stored.Name())
+
+		err = store.UpdatePortIfVersion(context.Background(), newRandomDomainPort(t), 1)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("compare and set delete", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+
+		record, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+
+		err = store.DeletePortIfVersion(context.Background(), port.Id(), record.Version+1)
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		require.NoError(t, store.DeletePortIfVersion(context.Background(), port.Id(), record.Version))
+
+		_, err = store.GetPort(context.Background(), port.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("versions are not reused after a delete", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+
+		deleted, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.NoError(t, store.DeleteAllPorts(context.Background()))
+
+		createRandomPortAndVerify(t, store, port)
+
+		recreated, err := store.GetPortRecord(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Greater(t, recreated.Version, deleted.Version)
+	})
+}
+
+func TestPortStore_History(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records every revision", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		beforeUpdate := time.Now()
+		time.Sleep(time.Millisecond)
+
+		updated, err := domain.NewPort(port.Id(), "updated", port.Code(), port.City(), port.Country(), nil, nil, nil, "", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), updated))
+		beforeDelete := time.Now()
+		time.Sleep(time.Millisecond)
+
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+
+		history, err := store.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		require.Equal(t, port, history[0].Port)
+		require.Equal(t, updated, history[1].Port)
+		require.True(t, history[2].Deleted)
+		require.Nil(t, history[2].Port)
+		require.Less(t, history[0].Version, history[1].Version)
+		require.Less(t, history[1].Version, history[2].Version)
+
+		asOf, err := store.GetPortAsOf(context.Background(), port.Id(), beforeUpdate)
+		require.NoError(t, err)
+		require.Equal(t, port, asOf.Port)
+
+		asOf, err = store.GetPortAsOf(context.Background(), port.Id(), beforeDelete)
+		require.NoError(t, err)
+		require.Equal(t, updated, asOf.Port)
+
+		_, err = store.GetPortAsOf(context.Background(), port.Id(), time.Now())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		_, err = store.GetPortAsOf(context.Background(), port.Id(), history[0].At.Add(-time.Second))
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("keeps the latest revisions", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+		var changes []domain.PortChange
+		store.OnChange(func(change domain.PortChange) {
+			changes = append(changes, change)
+		})
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		afterCreate := time.Now()
+		time.Sleep(time.Millisecond)
+		for _, name := range []string{"first", "second"} {
+			require.NoError(t, port.SetName(name))
+			require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+		}
+
+		store.LimitHistory(2)
+		history, err := store.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, "first", history[0].Port.Name())
+
+		require.NoError(t, port.SetName("third"))
+		require.NoError(t, store.CreateOrUpdatePort(context.Background(), port))
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+
+		history, err = store.PortHistory(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, "third", history[0].Port.Name())
+		require.True(t, history[1].Deleted)
+
+		_, err = store.GetPortAsOf(context.Background(), port.Id(), afterCreate)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		// the changes are told before the revisions they follow are dropped
+		require.Equal(t, domain.ChangeUpdated, changes[3].Kind)
+		require.Equal(t, domain.ChangeDeleted, changes[4].Kind)
+		require.Equal(t, "third", changes[4].Port.Name())
+	})
+
+	t.Run("unknown port", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		_, err := store.PortHistory(context.Background(), "unknown")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		kept := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, kept)
+		sequence := store.Sequence()
+
+		createRandomPortAndVerify(t, store, newRandomDomainPort(t))
+		require.NoError(t, store.DeleteAllPorts(context.Background()))
+
+		store.Rollback(sequence)
+
+		count, err := store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		history, err := store.PortHistory(context.Background(), kept.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		require.Equal(t, kept, history[0].Port)
+	})
+}
+
+func TestPortStore_SoftDelete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deleted ports are hidden", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+
+		_, err := store.GetPort(context.Background(), port.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		count, err := store.CountPorts(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		page, err := store.ListPorts(context.Background(), domain.PortQuery{})
+		require.NoError(t, err)
+		require.Empty(t, page.Ports)
+
+		page, err = store.ListPorts(context.Background(), domain.PortQuery{IncludeDeleted: true})
+		require.NoError(t, err)
+		require.Len(t, page.Ports, 1)
+		require.True(t, page.Ports[0].IsDeleted())
+
+		tombstone, err := store.GetDeletedPort(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.True(t, port.Equal(tombstone.Port))
+		require.False(t, tombstone.Port.DeletedAt().IsZero())
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+
+		err := store.RestorePort(context.Background(), port.Id())
+		require.ErrorIs(t, err, domain.ErrAlreadyExists)
+
+		require.NoError(t, store.DeleteAllPorts(context.Background()))
+		require.NoError(t, store.RestorePort(context.Background(), port.Id()))
+
+		restored, err := store.GetPort(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.Equal(t, port, restored)
+
+		_, err = store.GetDeletedPort(context.Background(), port.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		err = store.RestorePort(context.Background(), "unknown")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		old := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, old)
+		created := time.Now()
+		require.NoError(t, store.DeletePortById(context.Background(), old.Id()))
+		cutoff := time.Now()
+		time.Sleep(time.Millisecond)
+
+		recent := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, recent)
+		require.NoError(t, store.DeletePortById(context.Background(), recent.Id()))
+
+		purged, err := store.PurgeDeletedPorts(context.Background(), cutoff)
+		require.NoError(t, err)
+		require.Equal(t, []string{old.Id()}, purged)
+
+		err = store.RestorePort(context.Background(), old.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		require.NoError(t, store.RestorePort(context.Background(), recent.Id()))
+
+		// nothing is left of a purged port
+		_, err = store.PortHistory(context.Background(), old.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = store.GetPortAsOf(context.Background(), old.Id(), created)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		history, err := store.PortHistory(context.Background(), recent.Id())
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+	})
+
+	t.Run("purge keeps the tombstone of a port deleted again", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+		cutoff := time.Now()
+		time.Sleep(time.Millisecond)
+
+		require.NoError(t, store.RestorePort(context.Background(), port.Id()))
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+
+		purged, err := store.PurgeDeletedPorts(context.Background(), cutoff)
+		require.NoError(t, err)
+		require.Empty(t, purged)
+		tombstone, err := store.GetDeletedPort(context.Background(), port.Id())
+		require.NoError(t, err)
+		require.True(t, tombstone.Port.DeletedAt().After(cutoff))
+	})
+
+	t.Run("recreating a deleted port drops its tombstone", func(t *testing.T) {
+		t.Parallel()
+		store := NewPortStore()
+
+		port := newRandomDomainPort(t)
+		createRandomPortAndVerify(t, store, port)
+		require.NoError(t, store.DeletePortById(context.Background(), port.Id()))
+		createRandomPortAndVerify(t, store, port)
+
+		_, err := store.GetDeletedPort(context.Background(), port.Id())
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -1,6 +1,8 @@
 package inmem
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -80,3 +82,23 @@ func newTestDomainPort(t *testing.T) *domain.Port {
 	require.NoError(t, err)
 	return port
 }
+
+func TestPort_JSON(t *testing.T) {
+	t.Parallel()
+
+	live := newTestStorePort(t)
+	data, err := json.Marshal(live)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "DeletedAt")
+
+	// older versions wrote the zero time for live ports
+	var old Port
+	require.NoError(t, json.Unmarshal([]byte(`{"Id":"AEAJM","Name":"Ajman","City":"Ajman","Country":"United Arab Emirates","DeletedAt":"0001-01-01T00:00:00Z"}`), &old))
+	store := NewPortStore()
+	store.Restore([]*Port{&old}, nil)
+
+	port, err := store.GetPort(context.Background(), "AEAJM")
+	require.NoError(t, err)
+	require.False(t, port.IsDeleted())
+	require.Empty(t, store.DeletedBefore(time.Now()))
+}
@@ -0,0 +1,113 @@
+package inmem
+
+import (
+	"sort"
+	"strings"
+)
+
+// prefixEntry is a value of a port field that prefixes are matched against.
+type prefixEntry struct {
+	// key is the lower-cased value the entries are sorted by.
+	key   string
+	id    string
+	field string
+	value string
+}
+
+type prefixHit struct {
+	id    string
+	field string
+	value string
+}
+
+// prefixIndex keeps the names and unlocs of the ports sorted, so that the
+// values starting with a prefix are next to each other. It is updated in
+// place on every write rather than rebuilt for lookups.
+type prefixIndex struct {
+	entries []prefixEntry
+}
+
+func newPrefixIndex() *prefixIndex {
+	return &prefixIndex{}
+}
+
+func prefixEntries(port *Port) []prefixEntry {
+	entries := make([]prefixEntry, 0, 1+len(port.Unlocs))
+	if port.Name != "" {
+		entries = append(entries, prefixEntry{key: prefixKey(port.Name), id: port.Id, field: "name", value: port.Name})
+	}
+	for _, unloc := range port.Unlocs {
+		if unloc != "" {
+			entries = append(entries, prefixEntry{key: prefixKey(unloc), id: port.Id, field: "unloc", value: unloc})
+		}
+	}
+
+	return entries
+}
+
+func (pi *prefixIndex) add(port *Port) {
+	for _, entry := range prefixEntries(port) {
+		i := pi.position(entry)
+		if i < len(pi.entries) && pi.entries[i] == entry {
+			continue
+		}
+		pi.entries = append(pi.entries, prefixEntry{})
+		copy(pi.entries[i+1:], pi.entries[i:])
+		pi.entries[i] = entry
+	}
+}
+
+func (pi *prefixIndex) remove(port *Port) {
+	for _, entry := range prefixEntries(port) {
+		i := pi.position(entry)
+		if i < len(pi.entries) && pi.entries[i] == entry {
+			pi.entries = append(pi.entries[:i], pi.entries[i+1:]...)
+		}
+	}
+}
+
+// position returns where the entry is, or would be, in the sorted entries.
+func (pi *prefixIndex) position(entry prefixEntry) int {
+	return sort.Search(len(pi.entries), func(i int) bool {
+		e := pi.entries[i]
+		if e.key != entry.key {
+			return e.key >= entry.key
+		}
+		if e.id != entry.id {
+			return e.id >= entry.id
+		}
+		if e.field != entry.field {
+			return e.field >= entry.field
+		}
+		return e.value >= entry.value
+	})
+}
+
+// lookup returns up to limit ports having a value that starts with the
+// prefix, in the order of their values. A port matching on several values
+// is returned once, for the first of them.
+func (pi *prefixIndex) lookup(prefix string, limit int) []prefixHit {
+	prefix = prefixKey(prefix)
+	start := sort.Search(len(pi.entries), func(i int) bool {
+		return pi.entries[i].key >= prefix
+	})
+
+	var hits []prefixHit
+	seen := make(map[string]struct{})
+	for _, entry := range pi.entries[start:] {
+		if len(hits) == limit || !strings.HasPrefix(entry.key, prefix) {
+			break
+		}
+		if _, ok := seen[entry.id]; ok {
+			continue
+		}
+		seen[entry.id] = struct{}{}
+		hits = append(hits, prefixHit{id: entry.id, field: entry.field, value: entry.value})
+	}
+
+	return hits
+}
+
+func prefixKey(value string) string {
+	return strings.ToLower(value)
+}
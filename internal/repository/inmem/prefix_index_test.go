@@ -0,0 +1,82 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestPortStore_AutocompletePorts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewPortStore()
+	for _, port := range []struct {
+		id, name string
+		unlocs   []string
+	}{
+		{"AEAJM", "Ajman", []string{"AEAJM"}},
+		{"AEAUH", "Abu Dhabi", []string{"AEAUH"}},
+		{"AEJEA", "Jebel Ali", []string{"AEJEA"}},
+		{"NLAMS", "Amsterdam", []string{"NLAMS"}},
+	} {
+		p, err := domain.NewPort(port.id, port.name, "", port.name, "Netherlands", nil, nil, nil, "", "", port.unlocs)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(ctx, p))
+	}
+
+	autocomplete := func(prefix string, limit int) []domain.Suggestion {
+		suggestions, err := store.AutocompletePorts(ctx, domain.AutocompleteQuery{Prefix: prefix, Limit: limit})
+		require.NoError(t, err)
+		return suggestions
+	}
+
+	require.Equal(t, []domain.Suggestion{
+		{Id: "AEAUH", Name: "Abu Dhabi", Field: "name", Value: "Abu Dhabi"},
+		{Id: "AEAJM", Name: "Ajman", Field: "unloc", Value: "AEAJM"},
+		{Id: "AEJEA", Name: "Jebel Ali", Field: "unloc", Value: "AEJEA"},
+		{Id: "NLAMS", Name: "Amsterdam", Field: "name", Value: "Amsterdam"},
+	}, autocomplete("a", 0))
+
+	suggestions := autocomplete("ae", 0)
+	require.Len(t, suggestions, 3)
+	require.Equal(t, "unloc", suggestions[0].Field)
+
+	require.Len(t, autocomplete("ae", 2), 2)
+	require.Equal(t, "AEJEA", autocomplete("JEB", 0)[0].Id)
+	require.Empty(t, autocomplete("x", 0))
+
+	// the index follows writes
+	p, err := domain.NewPort("AEJEA", "Mina Jebel Ali", "", "Jebel Ali", "Netherlands", nil, nil, nil, "", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateOrUpdatePort(ctx, p))
+	require.Empty(t, autocomplete("jeb", 0))
+	require.Equal(t, "Mina Jebel Ali", autocomplete("mina", 0)[0].Name)
+
+	require.NoError(t, store.DeletePortById(ctx, "AEJEA"))
+	require.Empty(t, autocomplete("mina", 0))
+
+	_, err = store.AutocompletePorts(ctx, domain.AutocompleteQuery{})
+	require.ErrorIs(t, err, domain.ErrInvalidQuery)
+}
+
+func BenchmarkPortStore_AutocompletePorts(b *testing.B) {
+	ctx := context.Background()
+	store := NewPortStore()
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("XX%03d", i)
+		p, err := domain.NewPort(id, fmt.Sprintf("Port %d", i), "", "City", "Country", nil, nil, nil, "", "", []string{id})
+		require.NoError(b, err)
+		require.NoError(b, store.CreateOrUpdatePort(ctx, p))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := store.AutocompletePorts(ctx, domain.AutocompleteQuery{Prefix: "port 12"})
+		require.NoError(b, err)
+	}
+}
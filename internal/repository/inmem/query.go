@@ -0,0 +1,59 @@
+package inmem
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func matchesFilter(port *Port, filter domain.PortFilter) bool {
+	if filter.Country != "" && !strings.EqualFold(port.Country, filter.Country) {
+		return false
+	}
+	if filter.City != "" && !strings.EqualFold(port.City, filter.City) {
+		return false
+	}
+	if filter.Province != "" && !strings.EqualFold(port.Province, filter.Province) {
+		return false
+	}
+	if filter.Timezone != "" && !strings.EqualFold(port.Timezone, filter.Timezone) {
+		return false
+	}
+	if filter.Region != "" && !containsFold(port.Regions, filter.Region) {
+		return false
+	}
+	if filter.Unloc != "" && !containsFold(port.Unlocs, filter.Unloc) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+func sortKey(port *Port, by domain.PortSort) string {
+	if by == domain.SortByName {
+		return port.Name
+	}
+	return port.Id
+}
+
+// comparePort orders a port against a (key, id) position; ids break ties so
+// that the order is stable even when names repeat.
+func comparePort(port *Port, by domain.PortSort, key, id string) int {
+	if c := strings.Compare(sortKey(port, by), key); c != 0 {
+		return c
+	}
+	return strings.Compare(port.Id, id)
+}
+
+func sortPorts(ports []*Port, by domain.PortSort) {
+	sort.Slice(ports, func(i, j int) bool {
+		return comparePort(ports[i], by, sortKey(ports[j], by), ports[j].Id) < 0
+	})
+}
@@ -0,0 +1,163 @@
+package inmem
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minSearchScore is the relevance a port needs to be a search hit. It lets
+// a misspelled word or two through, such as "Abu Dabi" for "Abu Dhabi".
+const minSearchScore = 0.3
+
+// searchField is a text field of the port that is searched, with how much a
+// match in it weighs.
+type searchField struct {
+	name   string
+	weight float64
+	values func(port *Port) []string
+}
+
+var searchFields = []searchField{
+	{name: "name", weight: 1, values: func(port *Port) []string { return []string{port.Name} }},
+	{name: "alias", weight: 0.9, values: func(port *Port) []string { return port.Alias }},
+	{name: "city", weight: 0.8, values: func(port *Port) []string { return []string{port.City} }},
+	{name: "province", weight: 0.6, values: func(port *Port) []string { return []string{port.Province} }},
+}
+
+// searchText is the trigrams of a single value of a searched field.
+type searchText struct {
+	field  string
+	weight float64
+	grams  map[string]struct{}
+}
+
+type searchHit struct {
+	id    string
+	score float64
+	field string
+}
+
+// searchIndex is an inverted index from the trigrams of the searched fields
+// to the ports having them. Ranking compares the trigrams of the query with
+// those of every field value of the candidates.
+type searchIndex struct {
+	grams multiIndex
+	texts map[string][]searchText
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		grams: make(multiIndex),
+		texts: make(map[string][]searchText),
+	}
+}
+
+func (si *searchIndex) add(port *Port) {
+	var texts []searchText
+	for _, field := range searchFields {
+		for _, value := range field.values(port) {
+			grams := trigrams(value)
+			if len(grams) == 0 {
+				continue
+			}
+			texts = append(texts, searchText{field: field.name, weight: field.weight, grams: grams})
+			for gram := range grams {
+				si.grams.add(gram, port.Id)
+			}
+		}
+	}
+
+	if len(texts) > 0 {
+		si.texts[port.Id] = texts
+	}
+}
+
+func (si *searchIndex) remove(port *Port) {
+	for _, text := range si.texts[port.Id] {
+		for gram := range text.grams {
+			si.grams.remove(gram, port.Id)
+		}
+	}
+	delete(si.texts, port.Id)
+}
+
+// search returns up to limit ports matching the text, most relevant first.
+func (si *searchIndex) search(text string, limit int) []searchHit {
+	query := trigrams(text)
+	if len(query) == 0 {
+		return nil
+	}
+
+	candidates := make(map[string]struct{})
+	for gram := range query {
+		for id := range si.grams[gram] {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	hits := make([]searchHit, 0, len(candidates))
+	for id := range candidates {
+		best := searchHit{id: id}
+		for _, text := range si.texts[id] {
+			score := text.weight * dice(query, text.grams)
+			if score > best.score {
+				best.score = score
+				best.field = text.field
+			}
+		}
+		if best.score >= minSearchScore {
+			hits = append(hits, best)
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// trigrams returns the trigrams of the words of the text, each word padded
+// so that its first and last letters weigh as much as the others.
+func trigrams(text string) map[string]struct{} {
+	grams := make(map[string]struct{})
+	for _, word := range searchWords(text) {
+		runes := []rune("$" + word + "$")
+		for i := 0; i+3 <= len(runes); i++ {
+			grams[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+
+	return grams
+}
+
+// searchWords splits the text into lower-cased words of letters and digits.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// dice is the Sørensen–Dice coefficient of two sets of trigrams: 1 when
+// they are equal, 0 when they have nothing in common.
+func dice(a, b map[string]struct{}) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	shared := 0
+	for gram := range a {
+		if _, ok := b[gram]; ok {
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
@@ -0,0 +1,71 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestPortStore_SearchPorts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewPortStore()
+	for _, port := range []struct {
+		id, name, city, province string
+		alias                    []string
+	}{
+		{"AEAUH", "Abu Dhabi", "Abu Dhabi", "Abu Dhabi", nil},
+		{"AEJEA", "Jebel Ali", "Jebel Ali", "Dubai", []string{"Mina Jebel Ali"}},
+		{"AEDXB", "Dubai", "Dubai", "Dubayy [Dubai]", nil},
+		{"AEAJM", "Ajman", "Ajman", "Ajman", []string{"Port Rashid"}},
+	} {
+		p, err := domain.NewPort(port.id, port.name, "", port.city, "United Arab Emirates", port.alias, nil, nil, port.province, "", nil)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateOrUpdatePort(ctx, p))
+	}
+
+	search := func(text string) []domain.SearchHit {
+		hits, err := store.SearchPorts(ctx, domain.SearchQuery{Text: text})
+		require.NoError(t, err)
+		return hits
+	}
+
+	hits := search("Abu Dabi")
+	require.NotEmpty(t, hits)
+	require.Equal(t, "AEAUH", hits[0].Port.Id())
+	require.Equal(t, "name", hits[0].Field)
+
+	hits = search("jebel ali")
+	require.Equal(t, "AEJEA", hits[0].Port.Id())
+	require.InDelta(t, 1, hits[0].Score, 1e-9)
+
+	hits = search("Rashid")
+	require.Len(t, hits, 1)
+	require.Equal(t, "alias", hits[0].Field)
+
+	// scores are ranked
+	hits = search("Dubai")
+	require.Equal(t, "AEDXB", hits[0].Port.Id())
+	for i := 1; i < len(hits); i++ {
+		require.GreaterOrEqual(t, hits[i-1].Score, hits[i].Score)
+	}
+
+	require.Empty(t, search("Rotterdam"))
+
+	// the index follows writes
+	p, err := domain.NewPort("AEAJM", "Rotterdam", "", "Ajman", "United Arab Emirates", nil, nil, nil, "", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateOrUpdatePort(ctx, p))
+	require.Empty(t, search("Rashid"))
+	require.Len(t, search("Rotterdam"), 1)
+
+	require.NoError(t, store.DeletePortById(ctx, "AEAJM"))
+	require.Empty(t, search("Rotterdam"))
+
+	_, err = store.SearchPorts(ctx, domain.SearchQuery{Text: " "})
+	require.ErrorIs(t, err, domain.ErrInvalidQuery)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const (
+	// DefaultChangeBacklog is how many of the latest changes the feed keeps
+	// for watchers resuming after a disconnection.
+	DefaultChangeBacklog = 1000
+	// changeWatchBuffer is how many changes a watcher can fall behind
+	// before it is dropped.
+	changeWatchBuffer = 64
+)
+
+// ChangeFeed numbers the changes of the repository and fans them out to its
+// watchers. It keeps the latest changes in a ring buffer, so that a watcher
+// can resume where it left off.
+type ChangeFeed struct {
+	mu       sync.Mutex
+	sequence uint64
+	// backlog is the ring buffer of the latest changes, the oldest at
+	// backlog[next] once it is full.
+	backlog  []domain.PortChange
+	next     int
+	full     bool
+	watchers map[chan domain.PortChange]struct{}
+	// onPublish are called with every change published, see OnPublish.
+	onPublish []func(change domain.PortChange)
+	closed    bool
+}
+
+func NewChangeFeed(backlog int) *ChangeFeed {
+	return &ChangeFeed{
+		backlog:  make([]domain.PortChange, backlog),
+		watchers: make(map[chan domain.PortChange]struct{}),
+	}
+}
+
+// Publish numbers the change, keeps it in the backlog and sends it to every
+// watcher. A watcher too far behind to take it is dropped: its channel is
+// closed, and it has to resume from the backlog.
+func (f *ChangeFeed) Publish(change domain.PortChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sequence++
+	change.Sequence = f.sequence
+
+	if len(f.backlog) > 0 {
+		f.backlog[f.next] = change
+		f.next = (f.next + 1) % len(f.backlog)
+		f.full = f.full || f.next == 0
+	}
+
+	for _, fn := range f.onPublish {
+		fn(change)
+	}
+
+	for watcher := range f.watchers {
+		select {
+		case watcher <- change:
+		default:
+			delete(f.watchers, watcher)
+			close(watcher)
+		}
+	}
+}
+
+// OnPublish registers fn to be called with every change published from now
+// on, numbered, in the order they are published. Unlike a watcher, fn never
+// misses a change, but it is called with the lock of the feed and of the
+// repository held, so it must be quick and must not call back into either.
+func (f *ChangeFeed) OnPublish(fn func(change domain.PortChange)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.onPublish = append(f.onPublish, fn)
+}
+
+// Sequence returns the sequence of the last change published.
+func (f *ChangeFeed) Sequence() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.sequence
+}
+
+// Watch returns the changes published after the given sequence that the
+// feed still keeps, and a channel receiving those published from now on.
+// It fails with domain.ErrChangesExpired when some of the changes after
+// the sequence are no longer kept, or the sequence was never reached, and
+// with domain.ErrChangesClosed once the feed is closed. The channel is
+// closed when ctx is done, when the watcher falls too far behind, or when
+// the feed is closed.
+func (f *ChangeFeed) Watch(ctx context.Context, after uint64) ([]domain.PortChange, <-chan domain.PortChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, nil, domain.ErrChangesClosed
+	}
+
+	missed, err := f.since(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watcher := make(chan domain.PortChange, changeWatchBuffer)
+	f.watchers[watcher] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		f.unwatch(watcher)
+	}()
+
+	return missed, watcher, nil
+}
+
+// since returns the kept changes published after the given sequence.
+// Callers must hold the lock.
+func (f *ChangeFeed) since(after uint64) ([]domain.PortChange, error) {
+	if after > f.sequence {
+		return nil, domain.ErrChangesExpired
+	}
+
+	kept := uint64(f.next)
+	if f.full {
+		kept = uint64(len(f.backlog))
+	}
+	count := f.sequence - after
+	if count > kept {
+		return nil, domain.ErrChangesExpired
+	}
+
+	missed := make([]domain.PortChange, 0, count)
+	for i := len(f.backlog) - int(count); i < len(f.backlog); i++ {
+		missed = append(missed, f.backlog[(f.next+i)%len(f.backlog)])
+	}
+
+	return missed, nil
+}
+
+func (f *ChangeFeed) unwatch(watcher chan domain.PortChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, watching := f.watchers[watcher]; watching {
+		delete(f.watchers, watcher)
+		close(watcher)
+	}
+}
+
+// Close closes the channels of every watcher, and keeps new ones from
+// watching. It lets streams of changes end on shutdown.
+func (f *ChangeFeed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for watcher := range f.watchers {
+		delete(f.watchers, watcher)
+		close(watcher)
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+// EventHandler handles a domain event once the port it happened to is stored.
+type EventHandler func(ctx context.Context, event domain.Event)
+
+// Dispatcher hands domain events to every subscribed handler, in the order
+// the handlers subscribed.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Subscribe registers a handler for all events dispatched from now on.
+func (d *Dispatcher) Subscribe(handler EventHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers = append(d.handlers, handler)
+}
+
+// Dispatch runs the handlers for each event. A handler that panics is
+// logged and does not keep the others from running.
+func (d *Dispatcher) Dispatch(ctx context.Context, events ...domain.Event) {
+	d.mu.RLock()
+	handlers := d.handlers
+	d.mu.RUnlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			dispatchTo(ctx, handler, event)
+		}
+	}
+}
+
+func dispatchTo(ctx context.Context, handler EventHandler, event domain.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("handler of %s event of port %s panicked: %v", event.EventName(), event.PortId(), r)
+		}
+	}()
+
+	handler(ctx, event)
+}
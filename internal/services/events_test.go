@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := NewDispatcher()
+	var handled []string
+	dispatcher.Subscribe(func(ctx context.Context, event domain.Event) {
+		handled = append(handled, "first "+event.EventName())
+	})
+	dispatcher.Subscribe(func(ctx context.Context, event domain.Event) {
+		panic("handler failed")
+	})
+	dispatcher.Subscribe(func(ctx context.Context, event domain.Event) {
+		handled = append(handled, "third "+event.EventName())
+	})
+
+	renamed := domain.PortRenamed{Id: "AEAJM", OldName: "Ajman", NewName: "Ajman Port"}
+	assigned := domain.RegionAssigned{Id: "AEAJM", Region: "Gulf"}
+	dispatcher.Dispatch(context.Background(), renamed, assigned)
+
+	require.Equal(t, []string{
+		"first " + renamed.EventName(),
+		"third " + renamed.EventName(),
+		"first " + assigned.EventName(),
+		"third " + assigned.EventName(),
+	}, handled)
+}
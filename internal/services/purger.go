@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+// Purger periodically hard-deletes the tombstones of ports deleted longer
+// than the retention period ago.
+type Purger struct {
+	repo      PortRepository
+	retention time.Duration
+	interval  time.Duration
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func NewPurger(repo PortRepository, retention, interval time.Duration) *Purger {
+	return &Purger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Start runs a purge every interval until Close is called.
+func (p *Purger) Start() {
+	go func() {
+		defer close(p.stopped)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				_, err := p.Purge(context.Background())
+				if err != nil {
+					log.Errorf("could not purge deleted ports: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Purge hard-deletes the tombstones that outlived the retention period and
+// returns how many there were.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	ids, err := p.repo.PurgeDeletedPorts(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) > 0 {
+		log.Infof("purged %d deleted ports", len(ids))
+	}
+
+	return len(ids), nil
+}
+
+// Close stops a started purger and waits for a running purge to finish.
+func (p *Purger) Close() {
+	close(p.stop)
+	<-p.stopped
+}
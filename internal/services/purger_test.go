@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+)
+
+func TestPurger(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newStore := func(t *testing.T) *inmem.PortStore {
+		store := inmem.NewPortStore()
+		for _, id := range []string{"AEAJM", "NLRTM"} {
+			port, err := domain.NewPort(id, id, "", id, "Netherlands", nil, nil, nil, "", "", nil)
+			require.NoError(t, err)
+			require.NoError(t, store.CreatePort(ctx, port))
+		}
+		require.NoError(t, store.DeletePortById(ctx, "AEAJM"))
+		return store
+	}
+
+	t.Run("purge", func(t *testing.T) {
+		store := newStore(t)
+
+		purged, err := NewPurger(store, time.Hour, time.Hour).Purge(ctx)
+		require.NoError(t, err)
+		require.Zero(t, purged, "the tombstone is within the retention")
+
+		time.Sleep(time.Millisecond)
+		purged, err = NewPurger(store, time.Nanosecond, time.Hour).Purge(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, purged)
+
+		_, err = store.GetDeletedPort(ctx, "AEAJM")
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = store.GetPort(ctx, "NLRTM")
+		require.NoError(t, err)
+	})
+
+	t.Run("start", func(t *testing.T) {
+		store := newStore(t)
+
+		purger := NewPurger(store, time.Nanosecond, time.Millisecond)
+		purger.Start()
+		require.Eventually(t, func() bool {
+			_, err := store.GetDeletedPort(ctx, "AEAJM")
+			return err != nil
+		}, 5*time.Second, time.Millisecond)
+		purger.Close()
+	})
+}
@@ -2,44 +2,221 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
 )
 
 type PortRepository interface {
 	CreateOrUpdatePort(ctx context.Context, port *domain.Port) error
+	CreatePort(ctx context.Context, port *domain.Port) error
 	CountPorts(ctx context.Context) (int, error)
 	GetPort(ctx context.Context, id string) (*domain.Port, error)
+	GetPortRecord(ctx context.Context, id string) (*domain.PortRecord, error)
+	GetPortAsOf(ctx context.Context, id string, at time.Time) (*domain.PortRecord, error)
+	PortHistory(ctx context.Context, id string) ([]domain.PortRevision, error)
+	GetDeletedPort(ctx context.Context, id string) (*domain.PortRecord, error)
+	RestorePort(ctx context.Context, id string) error
+	PurgeDeletedPorts(ctx context.Context, before time.Time) ([]string, error)
+	UpdatePortIfVersion(ctx context.Context, port *domain.Port, version uint64) error
 	DeleteAllPorts(ctx context.Context) error
 	DeletePortById(ctx context.Context, id string) error
+	DeletePortIfVersion(ctx context.Context, id string, version uint64) error
+	ListPorts(ctx context.Context, query domain.PortQuery) (*domain.PortPage, error)
+	GetPortByUnloc(ctx context.Context, unloc string) (*domain.Port, error)
+	FindPortsByCode(ctx context.Context, code string) ([]*domain.Port, error)
+	FindPortsByAlias(ctx context.Context, alias string) ([]*domain.Port, error)
+	NearestPorts(ctx context.Context, lat, lon float64, k int) ([]domain.NearbyPort, error)
+	PortsWithin(ctx context.Context, box domain.BoundingBox) ([]domain.NearbyPort, error)
+	SearchPorts(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error)
+	AutocompletePorts(ctx context.Context, query domain.AutocompleteQuery) ([]domain.Suggestion, error)
+	ApplyBatch(ctx context.Context, batch *domain.PortBatch) error
+	ForEachPort(ctx context.Context, fn func(port *domain.Port) error) error
+	OnChange(fn func(change domain.PortChange))
 }
 
 type PortService struct {
-	repo PortRepository
+	repo    PortRepository
+	events  *Dispatcher
+	changes *ChangeFeed
 }
 
 func NewPortService(repo PortRepository) PortService {
+	changes := NewChangeFeed(DefaultChangeBacklog)
+	repo.OnChange(changes.Publish)
+
 	return PortService{
-		repo: repo,
+		repo:    repo,
+		events:  NewDispatcher(),
+		changes: changes,
 	}
 }
 
+// Subscribe registers a handler for the events of the ports the service stores.
+func (ps PortService) Subscribe(handler EventHandler) {
+	ps.events.Subscribe(handler)
+}
+
+// ChangeSequence returns the sequence of the last change made to the ports.
+func (ps PortService) ChangeSequence() uint64 {
+	return ps.changes.Sequence()
+}
+
+// WatchChanges returns the changes made after the given sequence and a
+// channel receiving those made from now on, see ChangeFeed.Watch.
+func (ps PortService) WatchChanges(ctx context.Context, after uint64) ([]domain.PortChange, <-chan domain.PortChange, error) {
+	return ps.changes.Watch(ctx, after)
+}
+
+// OnChange registers fn to be called with every change made to the ports
+// from now on, see ChangeFeed.OnPublish.
+func (ps PortService) OnChange(fn func(change domain.PortChange)) {
+	ps.changes.OnPublish(fn)
+}
+
+// CloseChanges ends every watch of the changes.
+func (ps PortService) CloseChanges() {
+	ps.changes.Close()
+}
+
 func (ps PortService) GetPort(ctx context.Context, id string) (*domain.Port, error) {
 	return ps.repo.GetPort(ctx, id)
 }
 
+func (ps PortService) GetPortRecord(ctx context.Context, id string) (*domain.PortRecord, error) {
+	return ps.repo.GetPortRecord(ctx, id)
+}
+
+func (ps PortService) GetPortAsOf(ctx context.Context, id string, at time.Time) (*domain.PortRecord, error) {
+	return ps.repo.GetPortAsOf(ctx, id, at)
+}
+
+func (ps PortService) PortHistory(ctx context.Context, id string) ([]domain.PortRevision, error) {
+	return ps.repo.PortHistory(ctx, id)
+}
+
+func (ps PortService) GetDeletedPort(ctx context.Context, id string) (*domain.PortRecord, error) {
+	return ps.repo.GetDeletedPort(ctx, id)
+}
+
+func (ps PortService) RestorePort(ctx context.Context, id string) error {
+	return ps.repo.RestorePort(ctx, id)
+}
+
+func (ps PortService) PurgeDeletedPorts(ctx context.Context, before time.Time) ([]string, error) {
+	return ps.repo.PurgeDeletedPorts(ctx, before)
+}
+
 func (ps PortService) CountPorts(ctx context.Context) (int, error) {
 	return ps.repo.CountPorts(ctx)
 }
 
 func (ps PortService) CreateOrUpdatePort(ctx context.Context, port *domain.Port) error {
-	return ps.repo.CreateOrUpdatePort(ctx, port)
+	err := ps.repo.CreateOrUpdatePort(ctx, port)
+	if err != nil {
+		return err
+	}
+
+	ps.events.Dispatch(ctx, port.PullEvents()...)
+	return nil
+}
+
+func (ps PortService) CreatePort(ctx context.Context, port *domain.Port) error {
+	err := ps.repo.CreatePort(ctx, port)
+	if err != nil {
+		return err
+	}
+
+	ps.events.Dispatch(ctx, port.PullEvents()...)
+	return nil
+}
+
+func (ps PortService) UpdatePortIfVersion(ctx context.Context, port *domain.Port, version uint64) error {
+	err := ps.repo.UpdatePortIfVersion(ctx, port, version)
+	if err != nil {
+		return err
+	}
+
+	ps.events.Dispatch(ctx, port.PullEvents()...)
+	return nil
+}
+
+// ModifyPort applies modify to the stored record of the port and stores its
+// port, unless the port was written in the meantime, in which case it fails
+// with domain.ErrVersionMismatch. modify changes the port through its
+// mutation methods, whose events are dispatched once it is stored.
+func (ps PortService) ModifyPort(ctx context.Context, id string, modify func(record *domain.PortRecord) error) error {
+	record, err := ps.repo.GetPortRecord(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = modify(record)
+	if err != nil {
+		return err
+	}
+
+	return ps.UpdatePortIfVersion(ctx, record.Port, record.Version)
 }
 
 func (ps PortService) DeletePortById(ctx context.Context, id string) error {
 	return ps.repo.DeletePortById(ctx, id)
 }
 
+func (ps PortService) DeletePortIfVersion(ctx context.Context, id string, version uint64) error {
+	return ps.repo.DeletePortIfVersion(ctx, id, version)
+}
+
 func (ps PortService) DeleteAllPorts(ctx context.Context) error {
 	return ps.repo.DeleteAllPorts(ctx)
 }
+
+func (ps PortService) ListPorts(ctx context.Context, query domain.PortQuery) (*domain.PortPage, error) {
+	return ps.repo.ListPorts(ctx, query)
+}
+
+func (ps PortService) GetPortByUnloc(ctx context.Context, unloc string) (*domain.Port, error) {
+	return ps.repo.GetPortByUnloc(ctx, unloc)
+}
+
+func (ps PortService) FindPortsByCode(ctx context.Context, code string) ([]*domain.Port, error) {
+	return ps.repo.FindPortsByCode(ctx, code)
+}
+
+func (ps PortService) FindPortsByAlias(ctx context.Context, alias string) ([]*domain.Port, error) {
+	return ps.repo.FindPortsByAlias(ctx, alias)
+}
+
+func (ps PortService) NearestPorts(ctx context.Context, lat, lon float64, k int) ([]domain.NearbyPort, error) {
+	return ps.repo.NearestPorts(ctx, lat, lon, k)
+}
+
+func (ps PortService) PortsWithin(ctx context.Context, box domain.BoundingBox) ([]domain.NearbyPort, error) {
+	return ps.repo.PortsWithin(ctx, box)
+}
+
+func (ps PortService) SearchPorts(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error) {
+	return ps.repo.SearchPorts(ctx, query)
+}
+
+func (ps PortService) AutocompletePorts(ctx context.Context, query domain.AutocompleteQuery) ([]domain.Suggestion, error) {
+	return ps.repo.AutocompletePorts(ctx, query)
+}
+
+func (ps PortService) ApplyBatch(ctx context.Context, batch *domain.PortBatch) error {
+	err := ps.repo.ApplyBatch(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range batch.Ops() {
+		if op.Port != nil {
+			ps.events.Dispatch(ctx, op.Port.PullEvents()...)
+		}
+	}
+	return nil
+}
+
+func (ps PortService) ForEachPort(ctx context.Context, fn func(port *domain.Port) error) error {
+	return ps.repo.ForEachPort(ctx, fn)
+}
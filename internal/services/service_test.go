@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+)
+
+func TestPortService_ModifyPort(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newService := func(t *testing.T) (PortService, *[]domain.Event) {
+		service := NewPortService(inmem.NewPortStore())
+		port, err := domain.NewPort("AEAJM", "Ajman", "52000", "Ajman", "United Arab Emirates", nil, nil, []float64{55.5136433, 25.4052165}, "Ajman", "Asia/Dubai", []string{"AEAJM"})
+		require.NoError(t, err)
+		require.NoError(t, service.CreatePort(ctx, port))
+
+		var events []domain.Event
+		service.Subscribe(func(ctx context.Context, event domain.Event) {
+			events = append(events, event)
+		})
+		return service, &events
+	}
+
+	t.Run("dispatches events once stored", func(t *testing.T) {
+		service, events := newService(t)
+
+		err := service.ModifyPort(ctx, "AEAJM", func(record *domain.PortRecord) error {
+			require.Empty(t, *events)
+			return record.Port.Rename("Ajman Port")
+		})
+		require.NoError(t, err)
+
+		stored, err := service.GetPort(ctx, "AEAJM")
+		require.NoError(t, err)
+		require.Equal(t, "Ajman Port", stored.Name())
+		require.Equal(t, []domain.Event{domain.PortRenamed{Id: "AEAJM", OldName: "Ajman", NewName: "Ajman Port"}}, *events)
+	})
+
+	t.Run("dispatches nothing when the store fails", func(t *testing.T) {
+		service, events := newService(t)
+
+		err := service.ModifyPort(ctx, "AEAJM", func(record *domain.PortRecord) error {
+			err := record.Port.Rename("Ajman Port")
+			require.NoError(t, err)
+
+			other, err := domain.NewPort("AEAJM", "Port of Ajman", "52000", "Ajman", "United Arab Emirates", nil, nil, nil, "", "", nil)
+			require.NoError(t, err)
+			return service.CreateOrUpdatePort(ctx, other)
+		})
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+		require.Empty(t, *events)
+	})
+
+	t.Run("unknown port", func(t *testing.T) {
+		service, events := newService(t)
+
+		err := service.ModifyPort(ctx, "AEQAJ", func(record *domain.PortRecord) error {
+			t.Fatal("modify must not be called")
+			return nil
+		})
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		require.Empty(t, *events)
+	})
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for a webhook whose host resolves to an
+// address of the host itself or of a private network.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// NewWebhookClient returns the client to post deliveries with. It refuses
+// to connect to loopback, link-local, private and other addresses that are
+// not public, so that webhooks cannot reach into the network the service
+// runs in, unless they are in one of the allowed networks, given in CIDR
+// notation. The address is checked once resolved, on every connection.
+func NewWebhookClient(timeout time.Duration, allowed []string) (*http.Client, error) {
+	networks := make([]netip.Prefix, 0, len(allowed))
+	for _, network := range allowed {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			return nil, fmt.Errorf("allowed webhook network %q: %w", network, err)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			return checkWebhookAddress(addrPort.Addr(), networks)
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// no proxy, the dialer has to see the address of the webhook
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}, nil
+}
+
+// checkWebhookAddress fails for an address that is not public and not in
+// one of the allowed networks.
+func checkWebhookAddress(addr netip.Addr, allowed []netip.Prefix) error {
+	addr = addr.Unmap()
+	for _, network := range allowed {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+
+	return nil
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+const (
+	// maxDeadLetters is how many failed deliveries are kept, the oldest are
+	// dropped first.
+	maxDeadLetters = 1000
+	// maxConcurrentDeliveries is how many deliveries are posted at once.
+	maxConcurrentDeliveries = 8
+	// maxQueuedDeliveries is how many deliveries wait for a webhook, those
+	// beyond become dead letters right away.
+	maxQueuedDeliveries = 256
+)
+
+// The headers of a delivery. The signature is the HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the secret of the webhook.
+const (
+	HeaderWebhookId        = "X-Webhook-Id"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// ChangeSource is where webhooks get the changes of the ports from,
+// PortService being one. fn must be called with every change, as it is
+// made.
+type ChangeSource interface {
+	OnChange(fn func(change domain.PortChange))
+}
+
+// ChangeEncoder encodes a change into the body of a delivery.
+type ChangeEncoder func(change domain.PortChange) ([]byte, error)
+
+// RetryPolicy is how often a delivery is attempted: after a failed attempt
+// the next one waits Backoff, doubled after every further failure up to
+// MaxBackoff.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// delay returns how long to wait after the given failed attempt, from 1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxBackoff)
+}
+
+// Webhooks keeps the registered webhooks and posts every change of the
+// ports, once it is stored, to those it matches. Each webhook gets its
+// changes one after the other, in the order they were made, from a queue of
+// its own. The deliveries are queued as the changes are made, so none is
+// missed. Failed deliveries are retried, and end up in the dead letters
+// once out of attempts, as do those that find the queue full. Webhooks and
+// dead letters are kept in memory.
+type Webhooks struct {
+	changes ChangeSource
+	encode  ChangeEncoder
+	client  *http.Client
+	retry   RetryPolicy
+
+	mu          sync.RWMutex
+	webhooks    map[string]domain.Webhook
+	queues      map[string]chan *delivery
+	queueSize   int
+	deadLetters []domain.DeadLetter
+
+	// posting limits the deliveries posted at once.
+	posting chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func NewWebhooks(changes ChangeSource, encode ChangeEncoder, client *http.Client, retry RetryPolicy) *Webhooks {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Webhooks{
+		changes:   changes,
+		encode:    encode,
+		client:    client,
+		retry:     retry,
+		webhooks:  make(map[string]domain.Webhook),
+		queues:    make(map[string]chan *delivery),
+		queueSize: maxQueuedDeliveries,
+		posting:   make(chan struct{}, maxConcurrentDeliveries),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start delivers the changes made from now on until Close is called.
+func (wh *Webhooks) Start() {
+	wh.changes.OnChange(wh.notify)
+}
+
+// Close stops delivering changes. Deliveries queued or waiting for a retry
+// are given up, Close waits for those being posted.
+func (wh *Webhooks) Close() {
+	wh.cancel()
+	wh.workers.Wait()
+}
+
+// CreateWebhook registers the webhook under a new id. A secret is made up
+// for it unless it has one.
+func (wh *Webhooks) CreateWebhook(_ context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	webhook, err := webhook.Normalize()
+	if err != nil {
+		return webhook, err
+	}
+
+	webhook.Id = uuid.New().String()
+	webhook.CreatedAt = time.Now()
+	if webhook.Secret == "" {
+		webhook.Secret, err = newWebhookSecret()
+		if err != nil {
+			return webhook, err
+		}
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	wh.webhooks[webhook.Id] = webhook
+
+	queue := make(chan *delivery, wh.queueSize)
+	wh.queues[webhook.Id] = queue
+	wh.workers.Add(1)
+	go func() {
+		defer wh.workers.Done()
+		wh.work(queue)
+	}()
+
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the URL and filters of the webhook, and its secret
+// when a new one is given.
+func (wh *Webhooks) UpdateWebhook(_ context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	webhook, err := webhook.Normalize()
+	if err != nil {
+		return webhook, err
+	}
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	stored, exists := wh.webhooks[webhook.Id]
+	if !exists {
+		return webhook, fmt.Errorf("webhook %s: %w", webhook.Id, domain.ErrNotFound)
+	}
+
+	webhook.CreatedAt = stored.CreatedAt
+	if webhook.Secret == "" {
+		webhook.Secret = stored.Secret
+	}
+
+	wh.webhooks[webhook.Id] = webhook
+	return webhook, nil
+}
+
+func (wh *Webhooks) GetWebhook(_ context.Context, id string) (domain.Webhook, error) {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	webhook, exists := wh.webhooks[id]
+	if !exists {
+		return webhook, fmt.Errorf("webhook %s: %w", id, domain.ErrNotFound)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns every webhook, the oldest first.
+func (wh *Webhooks) ListWebhooks(_ context.Context) ([]domain.Webhook, error) {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	webhooks := make([]domain.Webhook, 0, len(wh.webhooks))
+	for _, webhook := range wh.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	slices.SortFunc(webhooks, func(a, b domain.Webhook) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return webhooks, nil
+}
+
+// DeleteWebhook unregisters the webhook. Its deliveries already queued go
+// on.
+func (wh *Webhooks) DeleteWebhook(_ context.Context, id string) error {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	if _, exists := wh.webhooks[id]; !exists {
+		return fmt.Errorf("webhook %s: %w", id, domain.ErrNotFound)
+	}
+
+	delete(wh.webhooks, id)
+	close(wh.queues[id])
+	delete(wh.queues, id)
+	return nil
+}
+
+// DeadLetters returns the deliveries that failed every attempt, the oldest
+// first.
+func (wh *Webhooks) DeadLetters(_ context.Context) ([]domain.DeadLetter, error) {
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+
+	return slices.Clone(wh.deadLetters), nil
+}
+
+// SignWebhook returns the signature of a delivery, as sent in the
+// X-Webhook-Signature header.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// delivery is a change on its way to a webhook.
+type delivery struct {
+	id      string
+	webhook domain.Webhook
+	change  domain.PortChange
+	body    []byte
+}
+
+// notify queues the deliveries of the change to the webhooks it matches.
+// It is called as the change is made, so it never waits for a webhook.
+func (wh *Webhooks) notify(change domain.PortChange) {
+	if wh.ctx.Err() != nil {
+		return
+	}
+
+	wh.mu.RLock()
+	var body []byte
+	var dropped []*delivery
+	for _, webhook := range wh.webhooks {
+		if !webhook.Matches(change) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			body, err = wh.encode(change)
+			if err != nil {
+				wh.mu.RUnlock()
+				log.Errorf("change %d of port %s not delivered: %v", change.Sequence, change.Id, err)
+				return
+			}
+		}
+
+		d := &delivery{
+			id:      uuid.New().String(),
+			webhook: webhook,
+			change:  change,
+			body:    body,
+		}
+
+		select {
+		case wh.queues[webhook.Id] <- d:
+		default:
+			dropped = append(dropped, d)
+		}
+	}
+	wh.mu.RUnlock()
+
+	for _, d := range dropped {
+		wh.deadLetter(d, 0, errQueueFull)
+	}
+}
+
+// errQueueFull is the error of a delivery that found the queue of its
+// webhook full.
+var errQueueFull = errors.New("too many deliveries queued for the webhook")
+
+// work delivers the queued deliveries of a webhook one after the other,
+// until the webhook is deleted or Close is called.
+func (wh *Webhooks) work(queue <-chan *delivery) {
+	for {
+		select {
+		case d, ok := <-queue:
+			if !ok {
+				return
+			}
+			wh.deliver(d)
+		case <-wh.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver posts the delivery until it succeeds or runs out of attempts.
+func (wh *Webhooks) deliver(d *delivery) {
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		err = wh.post(d)
+		if err == nil {
+			return
+		}
+		if wh.ctx.Err() != nil {
+			return
+		}
+		if attempt >= wh.retry.Attempts {
+			break
+		}
+
+		timer := time.NewTimer(wh.retry.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-wh.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	wh.deadLetter(d, attempt, err)
+}
+
+// deadLetter keeps the delivery that failed after the given attempts.
+func (wh *Webhooks) deadLetter(d *delivery, attempts int, err error) {
+	log.Errorf("delivery %s of change %d to webhook %s failed after %d attempts: %v", d.id, d.change.Sequence, d.webhook.Id, attempts, err)
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	wh.deadLetters = append(wh.deadLetters, domain.DeadLetter{
+		DeliveryId: d.id,
+		WebhookId:  d.webhook.Id,
+		URL:        d.webhook.URL,
+		Change:     d.change,
+		Attempts:   attempts,
+		Error:      err.Error(),
+		FailedAt:   time.Now(),
+	})
+	if len(wh.deadLetters) > maxDeadLetters {
+		wh.deadLetters = slices.Delete(wh.deadLetters, 0, len(wh.deadLetters)-maxDeadLetters)
+	}
+}
+
+// post makes one attempt at the delivery.
+func (wh *Webhooks) post(d *delivery) error {
+	select {
+	case wh.posting <- struct{}{}:
+		defer func() { <-wh.posting }()
+	case <-wh.ctx.Done():
+		return wh.ctx.Err()
+	}
+
+	req, err := http.NewRequestWithContext(wh.ctx, http.MethodPost, d.webhook.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookId, d.webhook.Id)
+	req.Header.Set(HeaderWebhookDelivery, d.id)
+	req.Header.Set(HeaderWebhookEvent, string(d.change.Kind))
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(d.webhook.Secret, timestamp, d.body))
+
+	res, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// drain the body so that the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", res.Status)
+	}
+
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", fmt.Errorf("make up webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
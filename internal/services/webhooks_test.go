@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+)
+
+func TestSignWebhook(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "sha256=03239b1a8ff6000b6dc837b5ed276e29aadd2279c2f54f0fd09721e6c0c202e5", SignWebhook("secret", 1700000000, []byte(`{"id":"AEAJM"}`)))
+	require.NotEqual(t, SignWebhook("secret", 1700000000, []byte(`{}`)), SignWebhook("secret", 1700000001, []byte(`{}`)))
+	require.NotEqual(t, SignWebhook("secret", 1700000000, []byte(`{}`)), SignWebhook("other", 1700000000, []byte(`{}`)))
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{Attempts: 10, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	require.Equal(t, time.Second, policy.delay(1))
+	require.Equal(t, 2*time.Second, policy.delay(2))
+	require.Equal(t, 4*time.Second, policy.delay(3))
+	require.Equal(t, 5*time.Second, policy.delay(4))
+	require.Equal(t, 5*time.Second, policy.delay(100))
+}
+
+// webhookReceiver records the deliveries it gets, answering with the status
+// of the path they are posted to, as in /503.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	deliveries []*http.Request
+	bodies     []string
+	// hold, when set, is waited for before answering.
+	hold chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		receiver.mu.Lock()
+		receiver.deliveries = append(receiver.deliveries, r)
+		receiver.bodies = append(receiver.bodies, string(body))
+		hold := receiver.hold
+		receiver.mu.Unlock()
+
+		if hold != nil {
+			<-hold
+		}
+		status, err := strconv.Atoi(r.URL.Path[1:])
+		if err != nil {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver
+}
+
+func (r *webhookReceiver) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.bodies...)
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	encode := func(change domain.PortChange) ([]byte, error) {
+		return fmt.Appendf(nil, "%s %s", change.Kind, change.Id), nil
+	}
+	newPort := func(t *testing.T, id, country string) *domain.Port {
+		port, err := domain.NewPort(id, id, "", id, country, nil, nil, nil, "", "", nil)
+		require.NoError(t, err)
+		return port
+	}
+	start := func(t *testing.T, client *http.Client, retry RetryPolicy) (PortService, *Webhooks) {
+		service := NewPortService(inmem.NewPortStore())
+		webhooks := NewWebhooks(service, encode, client, retry)
+		webhooks.Start()
+		t.Cleanup(webhooks.Close)
+		return service, webhooks
+	}
+
+	t.Run("filters, in order and signed", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		service, webhooks := start(t, receiver.Client(), RetryPolicy{Attempts: 1})
+
+		webhook, err := webhooks.CreateWebhook(ctx, domain.Webhook{
+			URL:       receiver.URL,
+			Events:    []domain.ChangeKind{domain.ChangeCreated, domain.ChangeDeleted},
+			Countries: []string{"netherlands"},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, service.CreatePort(ctx, newPort(t, "AEAJM", "United Arab Emirates")))
+		for _, id := range []string{"NLRTM", "NLAMS", "NLVLI"} {
+			require.NoError(t, service.CreatePort(ctx, newPort(t, id, "Netherlands")))
+		}
+		require.NoError(t, service.CreateOrUpdatePort(ctx, newPort(t, "NLRTM", "Netherlands")))
+		require.NoError(t, service.DeletePortById(ctx, "NLRTM"))
+
+		expected := []string{"created NLRTM", "created NLAMS", "created NLVLI", "deleted NLRTM"}
+		require.Eventually(t, func() bool {
+			return len(receiver.received()) == len(expected)
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, expected, receiver.received())
+
+		delivery := receiver.deliveries[0]
+		timestamp, err := strconv.ParseInt(delivery.Header.Get(HeaderWebhookTimestamp), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, webhook.Id, delivery.Header.Get(HeaderWebhookId))
+		require.Equal(t, string(domain.ChangeCreated), delivery.Header.Get(HeaderWebhookEvent))
+		require.Equal(t, SignWebhook(webhook.Secret, timestamp, []byte(expected[0])), delivery.Header.Get(HeaderWebhookSignature))
+	})
+
+	t.Run("retries and dead letters", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		service, webhooks := start(t, receiver.Client(), RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+
+		webhook, err := webhooks.CreateWebhook(ctx, domain.Webhook{URL: receiver.URL + "/503"})
+		require.NoError(t, err)
+		require.NoError(t, service.CreatePort(ctx, newPort(t, "NLRTM", "Netherlands")))
+
+		var deadLetters []domain.DeadLetter
+		require.Eventually(t, func() bool {
+			deadLetters, err = webhooks.DeadLetters(ctx)
+			require.NoError(t, err)
+			return len(deadLetters) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.Equal(t, webhook.Id, deadLetters[0].WebhookId)
+		require.Equal(t, "NLRTM", deadLetters[0].Change.Id)
+		require.Equal(t, 3, deadLetters[0].Attempts)
+		require.Contains(t, deadLetters[0].Error, "503")
+		require.Equal(t, []string{"created NLRTM", "created NLRTM", "created NLRTM"}, receiver.received())
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		receiver.hold = make(chan struct{})
+		service := NewPortService(inmem.NewPortStore())
+		webhooks := NewWebhooks(service, encode, receiver.Client(), RetryPolicy{Attempts: 1})
+		webhooks.queueSize = 1
+		webhooks.Start()
+		t.Cleanup(webhooks.Close)
+		t.Cleanup(func() { close(receiver.hold) })
+
+		_, err := webhooks.CreateWebhook(ctx, domain.Webhook{URL: receiver.URL})
+		require.NoError(t, err)
+		for _, id := range []string{"NLRTM", "NLAMS", "NLVLI", "NLDZL"} {
+			require.NoError(t, service.CreatePort(ctx, newPort(t, id, "Netherlands")))
+		}
+
+		// one delivery is being posted, one queued, the others dropped
+		var deadLetters []domain.DeadLetter
+		require.Eventually(t, func() bool {
+			deadLetters, err = webhooks.DeadLetters(ctx)
+			require.NoError(t, err)
+			return len(deadLetters) >= 2
+		}, 5*time.Second, 10*time.Millisecond)
+		for _, deadLetter := range deadLetters {
+			require.Zero(t, deadLetter.Attempts)
+			require.Equal(t, errQueueFull.Error(), deadLetter.Error)
+		}
+	})
+
+	t.Run("more changes than the feed keeps", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		receiver.hold = make(chan struct{})
+		service, webhooks := start(t, receiver.Client(), RetryPolicy{Attempts: 1})
+
+		_, err := webhooks.CreateWebhook(ctx, domain.Webhook{URL: receiver.URL, Events: []domain.ChangeKind{domain.ChangeDeleted}})
+		require.NoError(t, err)
+
+		const count = DefaultChangeBacklog + 200
+		batch := domain.NewPortBatch()
+		for i := range count {
+			require.NoError(t, batch.Insert(newPort(t, fmt.Sprintf("P%04d", i), "Netherlands")))
+		}
+		require.NoError(t, service.ApplyBatch(ctx, batch))
+		require.NoError(t, service.DeleteAllPorts(ctx))
+		close(receiver.hold)
+
+		// every deletion is delivered, or dead lettered for finding the
+		// queue full
+		deleted := make(map[string]bool, count)
+		require.Eventually(t, func() bool {
+			deadLetters, err := webhooks.DeadLetters(ctx)
+			require.NoError(t, err)
+			received := receiver.received()
+			if len(deadLetters)+len(received) < count {
+				return false
+			}
+
+			for _, deadLetter := range deadLetters {
+				deleted[deadLetter.Change.Id] = true
+			}
+			for _, body := range received {
+				deleted[strings.TrimPrefix(body, "deleted ")] = true
+			}
+			return true
+		}, 10*time.Second, 10*time.Millisecond)
+		require.Len(t, deleted, count)
+	})
+
+	t.Run("private address", func(t *testing.T) {
+		receiver := newWebhookReceiver(t)
+		client, err := NewWebhookClient(time.Second, nil)
+		require.NoError(t, err)
+		service, webhooks := start(t, client, RetryPolicy{Attempts: 1})
+
+		_, err = webhooks.CreateWebhook(ctx, domain.Webhook{URL: receiver.URL})
+		require.NoError(t, err)
+		require.NoError(t, service.CreatePort(ctx, newPort(t, "NLRTM", "Netherlands")))
+
+		var deadLetters []domain.DeadLetter
+		require.Eventually(t, func() bool {
+			deadLetters, err = webhooks.DeadLetters(ctx)
+			require.NoError(t, err)
+			return len(deadLetters) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Contains(t, deadLetters[0].Error, ErrForbiddenAddress.Error())
+		require.Empty(t, receiver.received())
+	})
+}
+
+func TestNewWebhookClient(t *testing.T) {
+	t.Parallel()
+
+	receiver := newWebhookReceiver(t)
+
+	client, err := NewWebhookClient(time.Second, nil)
+	require.NoError(t, err)
+	_, err = client.Post(receiver.URL, "text/plain", nil)
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+
+	client, err = NewWebhookClient(time.Second, []string{"10.0.0.0/8", "127.0.0.0/8"})
+	require.NoError(t, err)
+	res, err := client.Post(receiver.URL, "text/plain", nil)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	_, err = NewWebhookClient(time.Second, []string{"localhost"})
+	require.Error(t, err)
+}
+
+func TestCheckWebhookAddress(t *testing.T) {
+	t.Parallel()
+
+	for address, allowed := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"::ffff:127.0.0.1":   false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"0.0.0.0":            false,
+		"224.0.0.1":          false,
+	} {
+		err := checkWebhookAddress(netip.MustParseAddr(address), nil)
+		if allowed {
+			require.NoError(t, err, address)
+		} else {
+			require.ErrorIs(t, err, ErrForbiddenAddress, address)
+		}
+	}
+
+	require.NoError(t, checkWebhookAddress(netip.MustParseAddr("10.1.2.3"), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+}
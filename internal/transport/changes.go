@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const mediaTypeEventStream = "text/event-stream"
+
+// changeHeartbeat is how often an idle change stream sends a comment, to
+// keep proxies from closing it.
+const changeHeartbeat = 15 * time.Second
+
+// StreamPortChanges streams every change made to the ports as Server-Sent
+// Events named after the kind of change, with the sequence of the change as
+// event id. A client reconnecting with Last-Event-ID gets the changes it
+// missed first. When they are no longer kept, it gets a reset event instead
+// and has to reload the ports it caches.
+func (h HttpServer) StreamPortChanges(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		server.InternalError("streaming-unsupported", errors.New("response cannot be streamed"), w, r)
+		return
+	}
+
+	after := h.service.ChangeSequence()
+	if lastEventId := r.Header.Get("Last-Event-ID"); lastEventId != "" {
+		var err error
+		after, err = strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			server.BadRequest("invalid-last-event-id", err, w, r)
+			return
+		}
+	}
+
+	missed, changes, err := h.service.WatchChanges(r.Context(), after)
+	reset := errors.Is(err, domain.ErrChangesExpired)
+	if reset {
+		after = h.service.ChangeSequence()
+		missed, changes, err = h.service.WatchChanges(r.Context(), after)
+	}
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if reset {
+		err = writeEvent(w, "reset", after, map[string]uint64{"sequence": after})
+	}
+	for i := 0; err == nil && i < len(missed); i++ {
+		err = writeChangeEvent(w, missed[i])
+	}
+	if err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(changeHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				// the watch ended, the client resumes once it reconnects
+				return
+			}
+			err = writeChangeEvent(w, change)
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeChangeEvent(w io.Writer, change domain.PortChange) error {
+	return writeEvent(w, string(change.Kind), change.Sequence, changeDomainToHttp(change))
+}
+
+// writeEvent writes a Server-Sent Event with the data encoded as JSON,
+// which never spans several lines.
+func writeEvent(w io.Writer, name string, id uint64, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, name, payload)
+	return err
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	commonerrors "github.com/zhenisduissekov/another-dummy-service/internal/common/errors"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const slugPreconditionFailed = "port-precondition-failed"
+
+// portETag is the strong entity tag of a port version.
+func portETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// etagMatches tells whether the If-Match or If-None-Match values list etag
+// or are "*". The weak comparison, used for If-None-Match, ignores W/ prefixes,
+// the strong one does not accept weak tags at all.
+func etagMatches(values []string, etag string, weak bool) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				return true
+			}
+
+			if strings.HasPrefix(tag, "W/") {
+				if !weak {
+					continue
+				}
+				tag = strings.TrimPrefix(tag, "W/")
+			}
+			if tag == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isConditional tells whether the request carries If-Match or If-None-Match.
+func isConditional(r *http.Request) bool {
+	return len(r.Header.Values("If-Match")) > 0 || len(r.Header.Values("If-None-Match")) > 0
+}
+
+// checkWritePreconditions evaluates If-Match and If-None-Match of a write
+// against the stored record, which is nil when the port does not exist.
+func checkWritePreconditions(r *http.Request, record *domain.PortRecord) error {
+	if ifMatch := r.Header.Values("If-Match"); len(ifMatch) > 0 {
+		if record == nil || !etagMatches(ifMatch, portETag(record.Version), false) {
+			return commonerrors.NewPreconditionFailedError("port does not match If-Match", slugPreconditionFailed)
+		}
+	}
+
+	if ifNoneMatch := r.Header.Values("If-None-Match"); len(ifNoneMatch) > 0 {
+		if record != nil && etagMatches(ifNoneMatch, portETag(record.Version), true) {
+			return commonerrors.NewPreconditionFailedError("port matches If-None-Match", slugPreconditionFailed)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package transport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+const mediaTypeGeoJSON = "application/geo+json"
+
+// portWriter encodes a stream of ports.
+type portWriter interface {
+	begin() error
+	write(port Port) error
+	end() error
+}
+
+type exportFormat struct {
+	name      string
+	mediaType string
+	extension string
+	newWriter func(w io.Writer) portWriter
+}
+
+var exportFormats = []exportFormat{
+	{
+		name:      "json",
+		mediaType: mediaTypeJSON,
+		extension: "json",
+		newWriter: func(w io.Writer) portWriter { return &jsonPortWriter{w: w} },
+	},
+	{
+		name:      "ndjson",
+		mediaType: mediaTypeNDJSON,
+		extension: "ndjson",
+		newWriter: func(w io.Writer) portWriter { return &ndjsonPortWriter{encoder: json.NewEncoder(w)} },
+	},
+	{
+		name:      "csv",
+		mediaType: mediaTypeCSV,
+		extension: "csv",
+		newWriter: func(w io.Writer) portWriter { return &csvPortWriter{w: csv.NewWriter(w)} },
+	},
+	{
+		name:      "geojson",
+		mediaType: mediaTypeGeoJSON,
+		extension: "geojson",
+		newWriter: func(w io.Writer) portWriter { return &geoJSONPortWriter{w: w} },
+	},
+}
+
+// ExportPorts streams every stored port, ordered by id, in the format picked
+// by ?format= or the Accept header. Ports are written as they are read, so
+// an error halfway through can only be logged: the status is already sent.
+func (h HttpServer) ExportPorts(w http.ResponseWriter, r *http.Request) {
+	format, err := negotiateExportFormat(r)
+	if err != nil {
+		server.NotAcceptable("export-format", err, w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.mediaType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ports.%s"`, format.extension))
+	w.WriteHeader(http.StatusOK)
+
+	writer := format.newWriter(w)
+	err = writer.begin()
+	if err == nil {
+		err = h.service.ForEachPort(r.Context(), func(port *domain.Port) error {
+			return writer.write(portDomainToHttp(port))
+		})
+	}
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		log.Errorf("export of ports aborted: %v", err)
+	}
+}
+
+// negotiateExportFormat picks the format named by ?format=, or else the
+// first one of the Accept header that is supported. JSON is the default.
+func negotiateExportFormat(r *http.Request) (exportFormat, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		for _, format := range exportFormats {
+			if strings.EqualFold(format.name, name) {
+				return format, nil
+			}
+		}
+		return exportFormat{}, fmt.Errorf("unknown export format %q", name)
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportFormats[0], nil
+	}
+
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return exportFormats[0], nil
+		}
+		for _, format := range exportFormats {
+			if mediaType == format.mediaType {
+				return format, nil
+			}
+		}
+	}
+
+	return exportFormat{}, fmt.Errorf("none of the accepted media types %q can be exported", accept)
+}
+
+// jsonPortWriter writes the object keyed by port id that POST /ports accepts.
+type jsonPortWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (jw *jsonPortWriter) begin() error {
+	_, err := io.WriteString(jw.w, "{\n")
+	return err
+}
+
+func (jw *jsonPortWriter) write(port Port) error {
+	key, err := json.Marshal(port.Id)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(port)
+	if err != nil {
+		return err
+	}
+
+	separator := ""
+	if jw.count > 0 {
+		separator = ",\n"
+	}
+	jw.count++
+
+	_, err = fmt.Fprintf(jw.w, "%s  %s: %s", separator, key, value)
+	return err
+}
+
+func (jw *jsonPortWriter) end() error {
+	_, err := io.WriteString(jw.w, "\n}\n")
+	return err
+}
+
+type ndjsonPortWriter struct {
+	encoder *json.Encoder
+}
+
+func (nw *ndjsonPortWriter) begin() error {
+	return nil
+}
+
+func (nw *ndjsonPortWriter) write(port Port) error {
+	return nw.encoder.Encode(port)
+}
+
+func (nw *ndjsonPortWriter) end() error {
+	return nil
+}
+
+// csvPortWriter writes the columns and list separator the CSV upload reads by default.
+type csvPortWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvPortWriter) begin() error {
+	return cw.w.Write([]string{"id", "name", "code", "city", "country", "alias", "regions", "coordinates", "province", "timezone", "unlocs"})
+}
+
+func (cw *csvPortWriter) write(port Port) error {
+	coordinates := make([]string, 0, len(port.Coordinates))
+	for _, c := range port.Coordinates {
+		coordinates = append(coordinates, strconv.FormatFloat(c, 'f', -1, 64))
+	}
+
+	return cw.w.Write([]string{
+		port.Id,
+		port.Name,
+		port.Code,
+		port.City,
+		port.Country,
+		strings.Join(port.Alias, defaultCSVListSeparator),
+		strings.Join(port.Regions, defaultCSVListSeparator),
+		strings.Join(coordinates, defaultCSVListSeparator),
+		port.Province,
+		port.Timezone,
+		strings.Join(port.Unlocs, defaultCSVListSeparator),
+	})
+}
+
+func (cw *csvPortWriter) end() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// geoJSONPortWriter writes a FeatureCollection with a Point feature per port.
+type geoJSONPortWriter struct {
+	w     io.Writer
+	count int
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	Id         string           `json:"id"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties Port             `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func (gw *geoJSONPortWriter) begin() error {
+	_, err := io.WriteString(gw.w, `{"type":"FeatureCollection","features":[`+"\n")
+	return err
+}
+
+func (gw *geoJSONPortWriter) write(port Port) error {
+	feature := geoJSONFeature{
+		Type:       "Feature",
+		Id:         port.Id,
+		Properties: port,
+	}
+	// ports are stored as [longitude, latitude], the GeoJSON order
+	if len(port.Coordinates) == 2 {
+		feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: port.Coordinates}
+	}
+
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+
+	separator := ""
+	if gw.count > 0 {
+		separator = ",\n"
+	}
+	gw.count++
+
+	_, err = fmt.Fprintf(gw.w, "%s%s", separator, data)
+	return err
+}
+
+func (gw *geoJSONPortWriter) end() error {
+	_, err := io.WriteString(gw.w, "\n]}\n")
+	return err
+}
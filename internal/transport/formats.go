@@ -0,0 +1,237 @@
+package transport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	mediaTypeJSON   = "application/json"
+	mediaTypeNDJSON = "application/x-ndjson"
+	mediaTypeCSV    = "text/csv"
+)
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// portReader decodes ports from r and sends them to portChan.
+type portReader func(ctx context.Context, r io.Reader, portChan chan Port) error
+
+// newPortReader picks the reader for the Content-Type of the request:
+// a JSON object keyed by port id (the default), newline delimited JSON
+// ports or CSV.
+func newPortReader(r *http.Request) (portReader, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return readPorts, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnsupportedMediaType, err)
+	}
+
+	switch mediaType {
+	case mediaTypeJSON:
+		return readPorts, nil
+	case mediaTypeNDJSON, "application/ndjson":
+		return readNDJSONPorts, nil
+	case mediaTypeCSV:
+		mapping, err := parseCSVMapping(r)
+		if err != nil {
+			return nil, err
+		}
+		return mapping.readPorts, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedMediaType, mediaType)
+	}
+}
+
+// readNDJSONPorts reads one port object, including its id, per line.
+func readNDJSONPorts(ctx context.Context, r io.Reader, portChan chan Port) error {
+	decoder := json.NewDecoder(r)
+
+	for line := 1; ; line++ {
+		// Check if context is cancelled.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var port Port
+		err := decoder.Decode(&port)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode port %d: %w", line, err)
+		}
+
+		select {
+		case portChan <- port:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// csvFields are the port fields a CSV column can be mapped to. Besides the
+// JSON field names, longitude and latitude may come in columns of their own.
+var csvFields = []string{
+	"id", "name", "code", "city", "country", "alias", "regions",
+	"coordinates", "longitude", "latitude", "province", "timezone", "unlocs",
+}
+
+const defaultCSVListSeparator = "|"
+
+// csvMapping tells which CSV column holds which port field and how the
+// values of multi-valued fields (alias, regions, unlocs and coordinates,
+// given as "longitude|latitude") are separated.
+type csvMapping struct {
+	// columns maps a port field to the header of its column.
+	columns       map[string]string
+	listSeparator string
+}
+
+// parseCSVMapping reads the mapping from the query: columns=field:header,...
+// renames the columns of fields, listSeparator replaces the default "|".
+// Fields not renamed are read from the column named after them.
+func parseCSVMapping(r *http.Request) (csvMapping, error) {
+	params := r.URL.Query()
+
+	mapping := csvMapping{
+		columns:       make(map[string]string, len(csvFields)),
+		listSeparator: defaultCSVListSeparator,
+	}
+	for _, field := range csvFields {
+		mapping.columns[field] = field
+	}
+
+	if columns := params.Get("columns"); columns != "" {
+		for _, pair := range strings.Split(columns, ",") {
+			field, header, ok := strings.Cut(pair, ":")
+			if !ok || header == "" {
+				return mapping, fmt.Errorf("invalid column mapping %q, expected field:header", pair)
+			}
+			if _, known := mapping.columns[field]; !known {
+				return mapping, fmt.Errorf("unknown port field %q in column mapping", field)
+			}
+			mapping.columns[field] = header
+		}
+	}
+
+	if separator := params.Get("listSeparator"); separator != "" {
+		mapping.listSeparator = separator
+	}
+
+	return mapping, nil
+}
+
+// readPorts reads a CSV document with a header line followed by one port per line.
+func (m csvMapping) readPorts(ctx context.Context, r io.Reader, portChan chan Port) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	// position of the column of every mapped field present in the document
+	positions := make(map[string]int)
+	for i, name := range header {
+		for field, column := range m.columns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				positions[field] = i
+			}
+		}
+	}
+	if _, ok := positions["id"]; !ok {
+		return fmt.Errorf("csv header has no %q column for the port id", m.columns["id"])
+	}
+
+	for {
+		// Check if context is cancelled.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read csv record: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		port, err := m.recordToPort(record, positions)
+		if err != nil {
+			return fmt.Errorf("invalid csv record on line %d: %w", line, err)
+		}
+
+		select {
+		case portChan <- port:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (m csvMapping) recordToPort(record []string, positions map[string]int) (Port, error) {
+	value := func(field string) string {
+		i, ok := positions[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	port := Port{
+		Id:       value("id"),
+		Name:     value("name"),
+		Code:     value("code"),
+		City:     value("city"),
+		Country:  value("country"),
+		Alias:    m.splitList(value("alias")),
+		Regions:  m.splitList(value("regions")),
+		Province: value("province"),
+		Timezone: value("timezone"),
+		Unlocs:   m.splitList(value("unlocs")),
+	}
+
+	lonLat := m.splitList(value("coordinates"))
+	if lon, lat := value("longitude"), value("latitude"); lon != "" || lat != "" {
+		lonLat = []string{lon, lat}
+	}
+	for _, raw := range lonLat {
+		coordinate, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return port, fmt.Errorf("invalid coordinate %q: %w", raw, err)
+		}
+		port.Coordinates = append(port.Coordinates, coordinate)
+	}
+
+	return port, nil
+}
+
+func (m csvMapping) splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, m.listSeparator) {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
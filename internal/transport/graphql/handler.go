@@ -0,0 +1,41 @@
+// Package graphql serves the ports over GraphQL at /graphql, alongside the
+// HTTP transport. The API is described in schema.graphql.
+package graphql
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+)
+
+const (
+	// maxRequestSize caps the body of a request, the query with its variables.
+	maxRequestSize = 1 << 20
+	// maxDepth caps how deeply a query nests its selections.
+	maxDepth = 10
+)
+
+//go:embed schema.graphql
+var schema string
+
+// NewHandler returns the handler of /graphql, taking queries posted as
+// {"query", "operationName", "variables"}.
+func NewHandler(service transport.PortService, validation domain.ValidationMode) http.Handler {
+	resolver := &Resolver{
+		service:    service,
+		validation: validation,
+	}
+	handler := &relay.Handler{
+		Schema: graphql.MustParseSchema(schema, resolver, graphql.MaxDepth(maxDepth)),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,210 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+	"github.com/zhenisduissekov/another-dummy-service/internal/services"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(services.NewPortService(inmem.NewPortStore()), domain.ValidationLenient)
+
+	const upsert = `mutation($port: PortInput!) { upsertPort(port: $port) { id name coordinates createdAt updatedAt } }`
+	for _, port := range []map[string]any{
+		{"id": "AEAJM", "name": "Ajman", "city": "Ajman", "country": "United Arab Emirates", "coordinates": []float64{55.5136433, 25.4052165}, "unlocs": []string{"AEAJM"}},
+		{"id": "AEAUH", "name": "Abu Dhabi", "city": "Abu Dhabi", "country": "United Arab Emirates", "coordinates": []float64{54.37, 24.47}},
+		{"id": "NLRTM", "name": "Rotterdam", "city": "Rotterdam", "country": "Netherlands", "coordinates": []float64{4.4, 51.9}},
+	} {
+		res := query(t, handler, upsert, map[string]any{"port": port})
+		require.Empty(t, res.Errors)
+	}
+
+	var upserted struct {
+		UpsertPort struct {
+			Id          string
+			Coordinates []float64
+			CreatedAt   string
+			UpdatedAt   string
+		}
+	}
+	res := query(t, handler, upsert, map[string]any{"port": map[string]any{"id": "AEAJM", "name": "Ajman", "city": "Ajman", "country": "United Arab Emirates"}})
+	require.Empty(t, res.Errors)
+	require.NoError(t, json.Unmarshal(res.Data, &upserted))
+	require.Empty(t, upserted.UpsertPort.Coordinates)
+	require.NotEmpty(t, upserted.UpsertPort.CreatedAt)
+	require.GreaterOrEqual(t, upserted.UpsertPort.UpdatedAt, upserted.UpsertPort.CreatedAt)
+
+	// an invalid port is rejected with the slug of the HTTP transport
+	res = query(t, handler, upsert, map[string]any{"port": map[string]any{"id": "BROKEN", "name": "", "city": "", "country": ""}})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "port-to-domain", res.Errors[0].Extensions["code"])
+
+	res = query(t, handler, `{ port(id: "NLRTM") { name country createdAt deletedAt } missing: port(id: "XXXXX") { name } count }`, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"port": {"name": "Rotterdam", "country": "Netherlands", "createdAt": "`+createdAt(t, handler, "NLRTM")+`", "deletedAt": null}, "missing": null, "count": 3}`, string(res.Data))
+
+	// page through the ports of a country
+	const list = `query($after: String) { ports(filter: {country: "united arab emirates"}, first: 1, after: $after) { nodes { id } pageInfo { endCursor hasNextPage } } }`
+	var ids []string
+	var after any
+	for {
+		var page struct {
+			Ports struct {
+				Nodes    []struct{ Id string }
+				PageInfo struct {
+					EndCursor   *string
+					HasNextPage bool
+				}
+			}
+		}
+		res := query(t, handler, list, map[string]any{"after": after})
+		require.Empty(t, res.Errors)
+		require.NoError(t, json.Unmarshal(res.Data, &page))
+		for _, node := range page.Ports.Nodes {
+			ids = append(ids, node.Id)
+		}
+		if !page.Ports.PageInfo.HasNextPage {
+			require.Nil(t, page.Ports.PageInfo.EndCursor)
+			break
+		}
+		after = *page.Ports.PageInfo.EndCursor
+	}
+	require.Equal(t, []string{"AEAJM", "AEAUH"}, ids)
+
+	res = query(t, handler, `{ ports(first: 0) { nodes { id } } }`, nil)
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "invalid-query", res.Errors[0].Extensions["code"])
+
+	var nearest struct {
+		Nearest []struct {
+			Port       struct{ Id string }
+			DistanceKm float64
+		}
+	}
+	res = query(t, handler, `{ nearest(lat: 25.2, lon: 55.3, k: 2) { port { id } distanceKm } }`, nil)
+	require.Empty(t, res.Errors)
+	require.NoError(t, json.Unmarshal(res.Data, &nearest))
+	require.Len(t, nearest.Nearest, 2)
+	require.Equal(t, "AEAUH", nearest.Nearest[0].Port.Id)
+	require.Less(t, nearest.Nearest[0].DistanceKm, nearest.Nearest[1].DistanceKm)
+
+	const remove = `mutation { deletePort(id: "NLRTM") }`
+	res = query(t, handler, remove, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"deletePort": true}`, string(res.Data))
+	res = query(t, handler, remove, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"deletePort": false}`, string(res.Data))
+
+	res = query(t, handler, `{ count }`, nil)
+	require.JSONEq(t, `{"count": 2}`, string(res.Data))
+}
+
+// countingService counts the records looked up one by one.
+type countingService struct {
+	transport.PortService
+	records atomic.Int32
+}
+
+func (s *countingService) GetPortRecord(ctx context.Context, id string) (*domain.PortRecord, error) {
+	s.records.Add(1)
+	return s.PortService.GetPortRecord(ctx, id)
+}
+
+func TestHandler_timestamps(t *testing.T) {
+	t.Parallel()
+
+	service := &countingService{PortService: services.NewPortService(inmem.NewPortStore())}
+	for i, id := range []string{"AEAJM", "AEAUH", "NLRTM"} {
+		port, err := domain.NewPort(id, id, "", id, "Netherlands", nil, nil, []float64{4 + float64(i), 52}, "", "", nil)
+		require.NoError(t, err)
+		require.NoError(t, service.CreatePort(context.Background(), port))
+	}
+	handler := NewHandler(service, domain.ValidationLenient)
+
+	var list struct {
+		Ports struct {
+			Nodes []struct {
+				Id        string
+				CreatedAt string
+				UpdatedAt string
+			}
+		}
+	}
+	res := query(t, handler, `{ ports { nodes { id createdAt updatedAt } } }`, nil)
+	require.Empty(t, res.Errors)
+	require.NoError(t, json.Unmarshal(res.Data, &list))
+	require.Len(t, list.Ports.Nodes, 3)
+	for _, node := range list.Ports.Nodes {
+		require.NotEmpty(t, node.CreatedAt, node.Id)
+		require.NotEmpty(t, node.UpdatedAt, node.Id)
+	}
+	require.Zero(t, service.records.Load(), "the listing holds the records")
+
+	var nearest struct {
+		Nearest []struct {
+			Port struct {
+				Id        string
+				CreatedAt string
+				UpdatedAt string
+			}
+		}
+	}
+	res = query(t, handler, `{ nearest(lat: 52, lon: 4, k: 3) { port { id createdAt updatedAt } } }`, nil)
+	require.Empty(t, res.Errors)
+	require.NoError(t, json.Unmarshal(res.Data, &nearest))
+	require.Len(t, nearest.Nearest, 3)
+	for _, node := range nearest.Nearest {
+		require.NotEmpty(t, node.Port.CreatedAt, node.Port.Id)
+		require.NotEmpty(t, node.Port.UpdatedAt, node.Port.Id)
+	}
+	require.Zero(t, service.records.Load(), "the nearest ports hold the records")
+}
+
+func query(t *testing.T, handler http.Handler, query string, variables map[string]any) response {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return res
+}
+
+func createdAt(t *testing.T, handler http.Handler, id string) string {
+	t.Helper()
+
+	var port struct {
+		Port struct{ CreatedAt string }
+	}
+	res := query(t, handler, `query($id: ID!) { port(id: $id) { createdAt } }`, map[string]any{"id": id})
+	require.Empty(t, res.Errors)
+	require.NoError(t, json.Unmarshal(res.Data, &port))
+	return port.Port.CreatedAt
+}
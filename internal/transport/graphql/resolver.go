@@ -0,0 +1,306 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+)
+
+// defaultNearest is the number of ports nearest returns without k, as in
+// GET /ports/nearest.
+const defaultNearest = 5
+
+// Resolver resolves the queries and mutations of the schema through the
+// same service as the HTTP transport.
+type Resolver struct {
+	service transport.PortService
+	// validation is the mode upserted ports are validated in.
+	validation domain.ValidationMode
+}
+
+func (r *Resolver) Port(ctx context.Context, args struct{ Id graphql.ID }) (*portResolver, error) {
+	record, err := r.service.GetPortRecord(ctx, string(args.Id))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	return newPortResolver(record), nil
+}
+
+type portFilter struct {
+	Country  *string
+	City     *string
+	Province *string
+	Timezone *string
+	Region   *string
+	Unloc    *string
+}
+
+func (r *Resolver) Ports(ctx context.Context, args struct {
+	Filter *portFilter
+	First  *int32
+	After  *string
+}) (*portConnectionResolver, error) {
+	query := domain.PortQuery{}
+	if args.Filter != nil {
+		query.Filter = domain.PortFilter{
+			Country:  valueOf(args.Filter.Country),
+			City:     valueOf(args.Filter.City),
+			Province: valueOf(args.Filter.Province),
+			Timezone: valueOf(args.Filter.Timezone),
+			Region:   valueOf(args.Filter.Region),
+			Unloc:    valueOf(args.Filter.Unloc),
+		}
+	}
+	if args.First != nil {
+		if *args.First <= 0 {
+			return nil, errorOf(fmt.Errorf("%w: first must be positive", domain.ErrInvalidQuery))
+		}
+		query.Limit = int(*args.First)
+	}
+	query.Cursor = valueOf(args.After)
+
+	page, err := r.service.ListPorts(ctx, query)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	nodes := make([]*portResolver, 0, len(page.Records))
+	for _, record := range page.Records {
+		nodes = append(nodes, newPortResolver(record))
+	}
+
+	return &portConnectionResolver{
+		nodes:      nodes,
+		nextCursor: page.NextCursor,
+	}, nil
+}
+
+func (r *Resolver) Count(ctx context.Context) (int32, error) {
+	count, err := r.service.CountPorts(ctx)
+	if err != nil {
+		return 0, errorOf(err)
+	}
+
+	return int32(count), nil
+}
+
+func (r *Resolver) Nearest(ctx context.Context, args struct {
+	Lat float64
+	Lon float64
+	K   *int32
+}) ([]*nearbyPortResolver, error) {
+	k := defaultNearest
+	if args.K != nil {
+		k = int(*args.K)
+	}
+
+	ports, err := r.service.NearestPorts(ctx, args.Lat, args.Lon, k)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	nearby := make([]*nearbyPortResolver, 0, len(ports))
+	for _, port := range ports {
+		nearby = append(nearby, &nearbyPortResolver{
+			port:       newPortResolver(port.Record),
+			distanceKm: port.DistanceKm,
+		})
+	}
+
+	return nearby, nil
+}
+
+type portInput struct {
+	Id          graphql.ID
+	Name        string
+	Code        *string
+	City        string
+	Country     string
+	Alias       *[]string
+	Regions     *[]string
+	Coordinates *[]float64
+	Province    *string
+	Timezone    *string
+	Unlocs      *[]string
+}
+
+// UpsertPort validates the port, creates or replaces it, and returns it as
+// stored.
+func (r *Resolver) UpsertPort(ctx context.Context, args struct{ Port portInput }) (*portResolver, error) {
+	input := args.Port
+	port, err := transport.PortHttpToDomain(&transport.Port{
+		Id:          string(input.Id),
+		Name:        input.Name,
+		Code:        valueOf(input.Code),
+		City:        input.City,
+		Country:     input.Country,
+		Alias:       valueOf(input.Alias),
+		Regions:     valueOf(input.Regions),
+		Coordinates: valueOf(input.Coordinates),
+		Province:    valueOf(input.Province),
+		Timezone:    valueOf(input.Timezone),
+		Unlocs:      valueOf(input.Unlocs),
+	}, r.validation)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	err = r.service.CreateOrUpdatePort(ctx, port)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	record, err := r.service.GetPortRecord(ctx, port.Id())
+	if err != nil {
+		return nil, errorOf(err)
+	}
+
+	return newPortResolver(record), nil
+}
+
+// DeletePort deletes the port, returning false when there is none.
+func (r *Resolver) DeletePort(ctx context.Context, args struct{ Id graphql.ID }) (bool, error) {
+	err := r.service.DeletePortById(ctx, string(args.Id))
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errorOf(err)
+	}
+
+	return true, nil
+}
+
+// portResolver resolves a port from its record, which the query reads
+// along with the port, so that the timestamps of every port it returns are
+// those of the revision it returns.
+type portResolver struct {
+	port   *domain.Port
+	record *domain.PortRecord
+}
+
+func newPortResolver(record *domain.PortRecord) *portResolver {
+	return &portResolver{
+		port:   record.Port,
+		record: record,
+	}
+}
+
+func (p *portResolver) ID() graphql.ID         { return graphql.ID(p.port.Id()) }
+func (p *portResolver) Name() string           { return p.port.Name() }
+func (p *portResolver) Code() string           { return p.port.Code() }
+func (p *portResolver) City() string           { return p.port.City() }
+func (p *portResolver) Country() string        { return p.port.Country() }
+func (p *portResolver) Alias() []string        { return nonNil(p.port.Alias()) }
+func (p *portResolver) Regions() []string      { return nonNil(p.port.Regions()) }
+func (p *portResolver) Coordinates() []float64 { return nonNil(p.port.Coordinates()) }
+func (p *portResolver) Province() string       { return p.port.Province() }
+func (p *portResolver) Timezone() string       { return p.port.Timezone() }
+func (p *portResolver) Unlocs() []string       { return nonNil(p.port.Unlocs()) }
+
+func (p *portResolver) DeletedAt() *string {
+	if !p.port.IsDeleted() {
+		return nil
+	}
+
+	deletedAt := formatTime(p.port.DeletedAt())
+	return &deletedAt
+}
+
+func (p *portResolver) CreatedAt() string { return formatTime(p.record.CreatedAt) }
+func (p *portResolver) UpdatedAt() string { return formatTime(p.record.UpdatedAt) }
+
+type portConnectionResolver struct {
+	nodes      []*portResolver
+	nextCursor string
+}
+
+func (c *portConnectionResolver) Nodes() []*portResolver { return c.nodes }
+
+func (c *portConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{nextCursor: c.nextCursor}
+}
+
+type pageInfoResolver struct {
+	nextCursor string
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	if p.nextCursor == "" {
+		return nil
+	}
+
+	return &p.nextCursor
+}
+
+func (p *pageInfoResolver) HasNextPage() bool { return p.nextCursor != "" }
+
+type nearbyPortResolver struct {
+	port       *portResolver
+	distanceKm float64
+}
+
+func (n *nearbyPortResolver) Port() *portResolver { return n.port }
+func (n *nearbyPortResolver) DistanceKm() float64 { return n.distanceKm }
+
+// queryError is an error of the service, with the slug the HTTP transport
+// answers it with as the code in its extensions.
+type queryError struct {
+	err  error
+	code string
+}
+
+func (e *queryError) Error() string { return e.err.Error() }
+func (e *queryError) Unwrap() error { return e.err }
+
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// errorOf turns an error of the service into one carrying its code.
+func errorOf(err error) error {
+	var fieldErr *domain.FieldError
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return &queryError{err: err, code: "port-not-found"}
+	case errors.As(err, &fieldErr), errors.Is(err, domain.ErrNil):
+		return &queryError{err: err, code: "port-to-domain"}
+	case errors.Is(err, domain.ErrInvalidQuery):
+		return &queryError{err: err, code: "invalid-query"}
+	default:
+		return &queryError{err: err, code: "internal-server-error"}
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func valueOf[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+
+	return *p
+}
+
+// nonNil keeps empty lists from resolving to null, the schema has them
+// non-null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+
+	return s
+}
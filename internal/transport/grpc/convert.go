@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/grpc/portspb"
+)
+
+// portProtoToDomain validates the port the way the HTTP transport does.
+func portProtoToDomain(port *portspb.Port, validation domain.ValidationMode) (*domain.Port, error) {
+	return transport.PortHttpToDomain(&transport.Port{
+		Id:          port.GetId(),
+		Name:        port.GetName(),
+		Code:        port.GetCode(),
+		City:        port.GetCity(),
+		Country:     port.GetCountry(),
+		Alias:       port.GetAlias(),
+		Regions:     port.GetRegions(),
+		Coordinates: port.GetCoordinates(),
+		Province:    port.GetProvince(),
+		Timezone:    port.GetTimezone(),
+		Unlocs:      port.GetUnlocs(),
+	}, validation)
+}
+
+func portDomainToProto(port *domain.Port) *portspb.Port {
+	return &portspb.Port{
+		Id:          port.Id(),
+		Name:        port.Name(),
+		Code:        port.Code(),
+		City:        port.City(),
+		Country:     port.Country(),
+		Alias:       port.Alias(),
+		Regions:     port.Regions(),
+		Coordinates: port.Coordinates(),
+		Province:    port.Province(),
+		Timezone:    port.Timezone(),
+		Unlocs:      port.Unlocs(),
+	}
+}
+
+func portFilterProtoToDomain(filter *portspb.PortFilter) domain.PortFilter {
+	return domain.PortFilter{
+		Country:  filter.GetCountry(),
+		City:     filter.GetCity(),
+		Province: filter.GetProvince(),
+		Timezone: filter.GetTimezone(),
+		Region:   filter.GetRegion(),
+		Unloc:    filter.GetUnloc(),
+	}
+}
@@ -0,0 +1,364 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ports.proto
+
+package portspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PortService_GetPort_FullMethodName        = "/ports.v1.PortService/GetPort"
+	PortService_CountPorts_FullMethodName     = "/ports.v1.PortService/CountPorts"
+	PortService_UpsertPort_FullMethodName     = "/ports.v1.PortService/UpsertPort"
+	PortService_DeletePort_FullMethodName     = "/ports.v1.PortService/DeletePort"
+	PortService_DeleteAllPorts_FullMethodName = "/ports.v1.PortService/DeleteAllPorts"
+	PortService_UploadPorts_FullMethodName    = "/ports.v1.PortService/UploadPorts"
+	PortService_ListPorts_FullMethodName      = "/ports.v1.PortService/ListPorts"
+)
+
+// PortServiceClient is the client API for PortService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PortService serves the ports over gRPC, alongside the HTTP transport.
+type PortServiceClient interface {
+	// GetPort returns the port with the given id, or NOT_FOUND.
+	GetPort(ctx context.Context, in *GetPortRequest, opts ...grpc.CallOption) (*Port, error)
+	// CountPorts returns how many ports are stored.
+	CountPorts(ctx context.Context, in *CountPortsRequest, opts ...grpc.CallOption) (*CountPortsResponse, error)
+	// UpsertPort creates the port, or replaces the one with the same id.
+	UpsertPort(ctx context.Context, in *UpsertPortRequest, opts ...grpc.CallOption) (*UpsertPortResponse, error)
+	// DeletePort deletes the port with the given id, or fails with NOT_FOUND.
+	DeletePort(ctx context.Context, in *DeletePortRequest, opts ...grpc.CallOption) (*DeletePortResponse, error)
+	// DeleteAllPorts deletes every port.
+	DeleteAllPorts(ctx context.Context, in *DeleteAllPortsRequest, opts ...grpc.CallOption) (*DeleteAllPortsResponse, error)
+	// UploadPorts upserts every port sent, stopping at the first invalid one.
+	UploadPorts(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Port, UploadPortsResponse], error)
+	// ListPorts streams the ports matching the filter.
+	ListPorts(ctx context.Context, in *ListPortsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Port], error)
+}
+
+type portServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPortServiceClient(cc grpc.ClientConnInterface) PortServiceClient {
+	return &portServiceClient{cc}
+}
+
+func (c *portServiceClient) GetPort(ctx context.Context, in *GetPortRequest, opts ...grpc.CallOption) (*Port, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Port)
+	err := c.cc.Invoke(ctx, PortService_GetPort_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) CountPorts(ctx context.Context, in *CountPortsRequest, opts ...grpc.CallOption) (*CountPortsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountPortsResponse)
+	err := c.cc.Invoke(ctx, PortService_CountPorts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) UpsertPort(ctx context.Context, in *UpsertPortRequest, opts ...grpc.CallOption) (*UpsertPortResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpsertPortResponse)
+	err := c.cc.Invoke(ctx, PortService_UpsertPort_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) DeletePort(ctx context.Context, in *DeletePortRequest, opts ...grpc.CallOption) (*DeletePortResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePortResponse)
+	err := c.cc.Invoke(ctx, PortService_DeletePort_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) DeleteAllPorts(ctx context.Context, in *DeleteAllPortsRequest, opts ...grpc.CallOption) (*DeleteAllPortsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAllPortsResponse)
+	err := c.cc.Invoke(ctx, PortService_DeleteAllPorts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *portServiceClient) UploadPorts(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Port, UploadPortsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PortService_ServiceDesc.Streams[0], PortService_UploadPorts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Port, UploadPortsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PortService_UploadPortsClient = grpc.ClientStreamingClient[Port, UploadPortsResponse]
+
+func (c *portServiceClient) ListPorts(ctx context.Context, in *ListPortsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Port], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PortService_ServiceDesc.Streams[1], PortService_ListPorts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListPortsRequest, Port]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PortService_ListPortsClient = grpc.ServerStreamingClient[Port]
+
+// PortServiceServer is the server API for PortService service.
+// All implementations must embed UnimplementedPortServiceServer
+// for forward compatibility.
+//
+// PortService serves the ports over gRPC, alongside the HTTP transport.
+type PortServiceServer interface {
+	// GetPort returns the port with the given id, or NOT_FOUND.
+	GetPort(context.Context, *GetPortRequest) (*Port, error)
+	// CountPorts returns how many ports are stored.
+	CountPorts(context.Context, *CountPortsRequest) (*CountPortsResponse, error)
+	// UpsertPort creates the port, or replaces the one with the same id.
+	UpsertPort(context.Context, *UpsertPortRequest) (*UpsertPortResponse, error)
+	// DeletePort deletes the port with the given id, or fails with NOT_FOUND.
+	DeletePort(context.Context, *DeletePortRequest) (*DeletePortResponse, error)
+	// DeleteAllPorts deletes every port.
+	DeleteAllPorts(context.Context, *DeleteAllPortsRequest) (*DeleteAllPortsResponse, error)
+	// UploadPorts upserts every port sent, stopping at the first invalid one.
+	UploadPorts(grpc.ClientStreamingServer[Port, UploadPortsResponse]) error
+	// ListPorts streams the ports matching the filter.
+	ListPorts(*ListPortsRequest, grpc.ServerStreamingServer[Port]) error
+	mustEmbedUnimplementedPortServiceServer()
+}
+
+// UnimplementedPortServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPortServiceServer struct{}
+
+func (UnimplementedPortServiceServer) GetPort(context.Context, *GetPortRequest) (*Port, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPort not implemented")
+}
+func (UnimplementedPortServiceServer) CountPorts(context.Context, *CountPortsRequest) (*CountPortsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountPorts not implemented")
+}
+func (UnimplementedPortServiceServer) UpsertPort(context.Context, *UpsertPortRequest) (*UpsertPortResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpsertPort not implemented")
+}
+func (UnimplementedPortServiceServer) DeletePort(context.Context, *DeletePortRequest) (*DeletePortResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePort not implemented")
+}
+func (UnimplementedPortServiceServer) DeleteAllPorts(context.Context, *DeleteAllPortsRequest) (*DeleteAllPortsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAllPorts not implemented")
+}
+func (UnimplementedPortServiceServer) UploadPorts(grpc.ClientStreamingServer[Port, UploadPortsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadPorts not implemented")
+}
+func (UnimplementedPortServiceServer) ListPorts(*ListPortsRequest, grpc.ServerStreamingServer[Port]) error {
+	return status.Errorf(codes.Unimplemented, "method ListPorts not implemented")
+}
+func (UnimplementedPortServiceServer) mustEmbedUnimplementedPortServiceServer() {}
+func (UnimplementedPortServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafePortServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PortServiceServer will
+// result in compilation errors.
+type UnsafePortServiceServer interface {
+	mustEmbedUnimplementedPortServiceServer()
+}
+
+func RegisterPortServiceServer(s grpc.ServiceRegistrar, srv PortServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPortServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PortService_ServiceDesc, srv)
+}
+
+func _PortService_GetPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).GetPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortService_GetPort_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).GetPort(ctx, req.(*GetPortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_CountPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).CountPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortService_CountPorts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).CountPorts(ctx, req.(*CountPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_UpsertPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertPortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).UpsertPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortService_UpsertPort_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).UpsertPort(ctx, req.(*UpsertPortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_DeletePort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).DeletePort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortService_DeletePort_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).DeletePort(ctx, req.(*DeletePortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_DeleteAllPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAllPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PortServiceServer).DeleteAllPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PortService_DeleteAllPorts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PortServiceServer).DeleteAllPorts(ctx, req.(*DeleteAllPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PortService_UploadPorts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PortServiceServer).UploadPorts(&grpc.GenericServerStream[Port, UploadPortsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PortService_UploadPortsServer = grpc.ClientStreamingServer[Port, UploadPortsResponse]
+
+func _PortService_ListPorts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListPortsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PortServiceServer).ListPorts(m, &grpc.GenericServerStream[ListPortsRequest, Port]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PortService_ListPortsServer = grpc.ServerStreamingServer[Port]
+
+// PortService_ServiceDesc is the grpc.ServiceDesc for PortService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PortService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ports.v1.PortService",
+	HandlerType: (*PortServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPort",
+			Handler:    _PortService_GetPort_Handler,
+		},
+		{
+			MethodName: "CountPorts",
+			Handler:    _PortService_CountPorts_Handler,
+		},
+		{
+			MethodName: "UpsertPort",
+			Handler:    _PortService_UpsertPort_Handler,
+		},
+		{
+			MethodName: "DeletePort",
+			Handler:    _PortService_DeletePort_Handler,
+		},
+		{
+			MethodName: "DeleteAllPorts",
+			Handler:    _PortService_DeleteAllPorts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadPorts",
+			Handler:       _PortService_UploadPorts_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListPorts",
+			Handler:       _PortService_ListPorts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ports.proto",
+}
@@ -0,0 +1,205 @@
+// Package grpc serves the ports over gRPC, alongside the HTTP transport.
+// The API is described in portspb/ports.proto.
+package grpc
+
+//go:generate protoc --proto_path=portspb --go_out=portspb --go_opt=paths=source_relative --go-grpc_out=portspb --go-grpc_opt=paths=source_relative ports.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/grpc/portspb"
+)
+
+// Server implements the gRPC PortService on top of the same service as
+// the HTTP transport.
+type Server struct {
+	portspb.UnimplementedPortServiceServer
+
+	service transport.PortService
+	// validation is the mode written ports are validated in.
+	validation domain.ValidationMode
+}
+
+func NewServer(service transport.PortService, validation domain.ValidationMode) *Server {
+	return &Server{
+		service:    service,
+		validation: validation,
+	}
+}
+
+// NewGrpcServer returns a gRPC server with the PortService registered.
+func NewGrpcServer(service transport.PortService, validation domain.ValidationMode) *grpc.Server {
+	srv := grpc.NewServer()
+	portspb.RegisterPortServiceServer(srv, NewServer(service, validation))
+
+	return srv
+}
+
+func (s *Server) GetPort(ctx context.Context, req *portspb.GetPortRequest) (*portspb.Port, error) {
+	port, err := s.service.GetPort(ctx, req.GetId())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+
+	return portDomainToProto(port), nil
+}
+
+func (s *Server) CountPorts(ctx context.Context, _ *portspb.CountPortsRequest) (*portspb.CountPortsResponse, error) {
+	count, err := s.service.CountPorts(ctx)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+
+	return &portspb.CountPortsResponse{Count: int64(count)}, nil
+}
+
+func (s *Server) UpsertPort(ctx context.Context, req *portspb.UpsertPortRequest) (*portspb.UpsertPortResponse, error) {
+	err := s.storePort(ctx, req.GetPort())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+
+	return &portspb.UpsertPortResponse{}, nil
+}
+
+func (s *Server) DeletePort(ctx context.Context, req *portspb.DeletePortRequest) (*portspb.DeletePortResponse, error) {
+	err := s.service.DeletePortById(ctx, req.GetId())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+
+	return &portspb.DeletePortResponse{}, nil
+}
+
+func (s *Server) DeleteAllPorts(ctx context.Context, _ *portspb.DeleteAllPortsRequest) (*portspb.DeleteAllPortsResponse, error) {
+	err := s.service.DeleteAllPorts(ctx)
+	if err != nil {
+		return nil, statusOf(err)
+	}
+
+	return &portspb.DeleteAllPortsResponse{}, nil
+}
+
+// UploadPorts stores the ports as they are received, the way the HTTP
+// upload does: a goroutine reads them into a channel while they are stored.
+func (s *Server) UploadPorts(stream portspb.PortService_UploadPortsServer) error {
+	ctx := stream.Context()
+
+	portChan := make(chan *portspb.Port)
+	doneChan := make(chan struct{}, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		err := receivePorts(ctx, stream, portChan)
+		if err != nil {
+			errChan <- err
+		} else {
+			doneChan <- struct{}{}
+		}
+	}()
+
+	portCounter := 0
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("upload stream cancelled")
+			return status.FromContextError(ctx.Err()).Err()
+		case <-doneChan:
+			log.Info("finished receiving ports")
+			return stream.SendAndClose(&portspb.UploadPortsResponse{TotalPorts: int64(portCounter)})
+		case err := <-errChan:
+			log.Infof("error while receiving ports: %+v", err)
+			return err
+		case port := <-portChan:
+			portCounter++
+			err := s.storePort(ctx, port)
+			if err != nil {
+				return status.Errorf(status.Code(statusOf(err)), "port %s: %v", port.GetId(), err)
+			}
+		}
+	}
+}
+
+// receivePorts sends the ports of the stream to portChan until the client
+// is done sending.
+func receivePorts(ctx context.Context, stream portspb.PortService_UploadPortsServer, portChan chan *portspb.Port) error {
+	for {
+		port, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case portChan <- port:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ListPorts streams the ports matching the filter, page by page.
+func (s *Server) ListPorts(req *portspb.ListPortsRequest, stream portspb.PortService_ListPortsServer) error {
+	query := domain.PortQuery{
+		Filter: portFilterProtoToDomain(req.GetFilter()),
+		Sort:   domain.PortSort(req.GetSort()),
+		Limit:  domain.MaxPageLimit,
+	}
+
+	for {
+		page, err := s.service.ListPorts(stream.Context(), query)
+		if err != nil {
+			return statusOf(err)
+		}
+
+		for _, port := range page.Ports {
+			err := stream.Send(portDomainToProto(port))
+			if err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// storePort validates the port and creates or updates it.
+func (s *Server) storePort(ctx context.Context, port *portspb.Port) error {
+	p, err := portProtoToDomain(port, s.validation)
+	if err != nil {
+		return err
+	}
+
+	return s.service.CreateOrUpdatePort(ctx, p)
+}
+
+// statusOf turns an error of the service into a gRPC status.
+func statusOf(err error) error {
+	var fieldErr *domain.FieldError
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.As(err, &fieldErr), errors.Is(err, domain.ErrNil), errors.Is(err, domain.ErrInvalidQuery):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
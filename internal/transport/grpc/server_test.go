@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
+	"github.com/zhenisduissekov/another-dummy-service/internal/services"
+	"github.com/zhenisduissekov/another-dummy-service/internal/transport/grpc/portspb"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	ajman := &portspb.Port{Id: "AEAJM", Name: "Ajman", City: "Ajman", Country: "United Arab Emirates", Coordinates: []float64{55.5136433, 25.4052165}, Unlocs: []string{"AEAJM"}}
+	_, err := client.UpsertPort(ctx, &portspb.UpsertPortRequest{Port: ajman})
+	require.NoError(t, err)
+
+	port, err := client.GetPort(ctx, &portspb.GetPortRequest{Id: "AEAJM"})
+	require.NoError(t, err)
+	require.Equal(t, "Ajman", port.GetName())
+	require.Equal(t, ajman.GetCoordinates(), port.GetCoordinates())
+
+	_, err = client.GetPort(ctx, &portspb.GetPortRequest{Id: "XXXXX"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.UpsertPort(ctx, &portspb.UpsertPortRequest{Port: &portspb.Port{Id: "AEAJM"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// upload a few ports in one stream
+	upload, err := client.UploadPorts(ctx)
+	require.NoError(t, err)
+	for _, p := range []*portspb.Port{
+		{Id: "AEAUH", Name: "Abu Dhabi", City: "Abu Dhabi", Country: "United Arab Emirates"},
+		{Id: "NLRTM", Name: "Rotterdam", City: "Rotterdam", Country: "Netherlands"},
+		{Id: "AEJEA", Name: "Jebel Ali", City: "Jebel Ali", Country: "United Arab Emirates"},
+	} {
+		require.NoError(t, upload.Send(p))
+	}
+	uploaded, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, uploaded.GetTotalPorts())
+
+	count, err := client.CountPorts(ctx, &portspb.CountPortsRequest{})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, count.GetCount())
+
+	// an invalid port stops the upload
+	upload, err = client.UploadPorts(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&portspb.Port{Id: "BROKEN"}))
+	_, err = upload.CloseAndRecv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := client.ListPorts(ctx, &portspb.ListPortsRequest{Filter: &portspb.PortFilter{Country: "united arab emirates"}})
+	require.NoError(t, err)
+	var ids []string
+	for {
+		port, err := list.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, port.GetId())
+	}
+	require.Equal(t, []string{"AEAJM", "AEAUH", "AEJEA"}, ids)
+
+	_, err = client.DeletePort(ctx, &portspb.DeletePortRequest{Id: "AEAJM"})
+	require.NoError(t, err)
+	_, err = client.DeletePort(ctx, &portspb.DeletePortRequest{Id: "AEAJM"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteAllPorts(ctx, &portspb.DeleteAllPortsRequest{})
+	require.NoError(t, err)
+	count, err = client.CountPorts(ctx, &portspb.CountPortsRequest{})
+	require.NoError(t, err)
+	require.Zero(t, count.GetCount())
+}
+
+// newTestClient serves an empty in-memory store over an in-process
+// connection and returns a client of it.
+func newTestClient(t *testing.T) portspb.PortServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	srv := NewGrpcServer(services.NewPortService(inmem.NewPortStore()), domain.ValidationLenient)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return portspb.NewPortServiceClient(conn)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	commonerrors "github.com/zhenisduissekov/another-dummy-service/internal/common/errors"
 	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
 	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
 	"github.com/zhenisduissekov/another-dummy-service/internal/log"
@@ -15,22 +19,55 @@ import (
 
 type PortService interface {
 	GetPort(ctx context.Context, id string) (*domain.Port, error)
+	GetPortRecord(ctx context.Context, id string) (*domain.PortRecord, error)
+	GetPortAsOf(ctx context.Context, id string, at time.Time) (*domain.PortRecord, error)
+	PortHistory(ctx context.Context, id string) ([]domain.PortRevision, error)
+	GetDeletedPort(ctx context.Context, id string) (*domain.PortRecord, error)
+	RestorePort(ctx context.Context, id string) error
+	PurgeDeletedPorts(ctx context.Context, before time.Time) ([]string, error)
 	CountPorts(ctx context.Context) (int, error)
 	CreateOrUpdatePort(ctx context.Context, port *domain.Port) error
+	CreatePort(ctx context.Context, port *domain.Port) error
+	UpdatePortIfVersion(ctx context.Context, port *domain.Port, version uint64) error
+	ModifyPort(ctx context.Context, id string, modify func(record *domain.PortRecord) error) error
 	DeleteAllPorts(ctx context.Context) error
 	DeletePortById(ctx context.Context, id string) error
+	DeletePortIfVersion(ctx context.Context, id string, version uint64) error
+	ListPorts(ctx context.Context, query domain.PortQuery) (*domain.PortPage, error)
+	GetPortByUnloc(ctx context.Context, unloc string) (*domain.Port, error)
+	FindPortsByCode(ctx context.Context, code string) ([]*domain.Port, error)
+	FindPortsByAlias(ctx context.Context, alias string) ([]*domain.Port, error)
+	NearestPorts(ctx context.Context, lat, lon float64, k int) ([]domain.NearbyPort, error)
+	PortsWithin(ctx context.Context, box domain.BoundingBox) ([]domain.NearbyPort, error)
+	SearchPorts(ctx context.Context, query domain.SearchQuery) ([]domain.SearchHit, error)
+	AutocompletePorts(ctx context.Context, query domain.AutocompleteQuery) ([]domain.Suggestion, error)
+	ApplyBatch(ctx context.Context, batch *domain.PortBatch) error
+	ForEachPort(ctx context.Context, fn func(port *domain.Port) error) error
+	ChangeSequence() uint64
+	WatchChanges(ctx context.Context, after uint64) ([]domain.PortChange, <-chan domain.PortChange, error)
 }
 
 type HttpServer struct {
 	service PortService
+	jobs    *jobRegistry
+	// validation is the mode writes are validated in unless they ask for another.
+	validation domain.ValidationMode
 }
 
-func NewHttpServer(service PortService) HttpServer {
+func NewHttpServer(service PortService, validation domain.ValidationMode) HttpServer {
 	return HttpServer{
-		service: service,
+		service:    service,
+		jobs:       newJobRegistry(),
+		validation: validation,
 	}
 }
 
+// CloseJobs cancels the upload jobs under way and waits for them to finish,
+// so that none writes past the shutdown. Jobs are refused from then on.
+func (h HttpServer) CloseJobs() {
+	h.jobs.close()
+}
+
 func (h HttpServer) CountPorts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -43,11 +80,33 @@ func (h HttpServer) CountPorts(w http.ResponseWriter, r *http.Request) {
 	server.RespondOK(map[string]int{"count": count}, w, r)
 }
 
+// GetPort is the deprecated alias /port?id= of GetPortById.
 func (h HttpServer) GetPort(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	id := r.URL.Query().Get("id")
+	deprecatePortAlias(id, w)
+	h.getPort(id, w, r)
+}
+
+// GetPortById answers with the port and its version as ETag. A request whose
+// If-None-Match lists the current version gets 304 Not Modified. With
+// ?includeDeleted=true a deleted port is answered from its tombstone, with
+// ?asOf=<RFC3339> the port is answered as it was at that instant.
+func (h HttpServer) GetPortById(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	asOf := r.URL.Query().Get("asOf")
+	if asOf == "" {
+		h.getPort(id, w, r)
+		return
+	}
 
-	port, err := h.service.GetPort(ctx, id)
+	at, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		server.BadRequest("invalid-as-of", err, w, r)
+		return
+	}
+
+	record, err := h.service.GetPortAsOf(r.Context(), id, at)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			server.NotFound("port-not-found", err, w, r)
@@ -57,31 +116,290 @@ func (h HttpServer) GetPort(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := Port{
-		Id:          port.Id(),
-		Name:        port.Name(),
-		Code:        port.Code(),
-		City:        port.City(),
-		Country:     port.Country(),
-		Alias:       port.Alias(),
-		Regions:     port.Regions(),
-		Coordinates: port.Coordinates(),
-		Province:    port.Province(),
-		Unlocs:      port.Unlocs(),
-		Timezone:    port.Timezone(),
+	respondWithPortRecord(record, w, r)
+}
+
+func (h HttpServer) getPort(id string, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		server.BadRequest("invalid-include-deleted", err, w, r)
+		return
+	}
+
+	record, err := h.service.GetPortRecord(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) && includeDeleted {
+		record, err = h.service.GetDeletedPort(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			server.NotFound("port-not-found", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	respondWithPortRecord(record, w, r)
+}
+
+func respondWithPortRecord(record *domain.PortRecord, w http.ResponseWriter, r *http.Request) {
+	etag := portETag(record.Version)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Values("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	server.RespondOK(portDomainToHttp(record.Port), w, r)
+}
+
+// GetPortHistory answers with every revision of the port, deletions
+// included, oldest first.
+func (h HttpServer) GetPortHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	history, err := h.service.PortHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			server.NotFound("port-not-found", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(revisionsDomainToHttp(history), w, r)
+}
+
+func (h HttpServer) ListPorts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	limit := 0
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			server.BadRequest("invalid-limit", err, w, r)
+			return
+		}
+	}
+
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		server.BadRequest("invalid-include-deleted", err, w, r)
+		return
+	}
+
+	query := domain.PortQuery{
+		Filter: domain.PortFilter{
+			Country:  params.Get("country"),
+			City:     params.Get("city"),
+			Province: params.Get("province"),
+			Timezone: params.Get("timezone"),
+			Region:   params.Get("region"),
+			Unloc:    params.Get("unloc"),
+		},
+		Sort:           domain.PortSort(params.Get("sort")),
+		Cursor:         params.Get("cursor"),
+		Limit:          limit,
+		IncludeDeleted: includeDeleted,
+	}
+
+	page, err := h.service.ListPorts(ctx, query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			server.BadRequest("invalid-query", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	response := PortList{
+		Ports:      portsDomainToHttp(page.Ports),
+		NextCursor: page.NextCursor,
 	}
 
 	server.RespondOK(response, w, r)
 }
 
+func (h HttpServer) GetPortByUnloc(w http.ResponseWriter, r *http.Request) {
+	unloc := mux.Vars(r)["unloc"]
+
+	port, err := h.service.GetPortByUnloc(r.Context(), unloc)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			server.NotFound("port-not-found", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(portDomainToHttp(port), w, r)
+}
+
+func (h HttpServer) FindPortsByCode(w http.ResponseWriter, r *http.Request) {
+	ports, err := h.service.FindPortsByCode(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(portsDomainToHttp(ports), w, r)
+}
+
+func (h HttpServer) FindPortsByAlias(w http.ResponseWriter, r *http.Request) {
+	ports, err := h.service.FindPortsByAlias(r.Context(), mux.Vars(r)["alias"])
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(portsDomainToHttp(ports), w, r)
+}
+
+func (h HttpServer) NearestPorts(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	lat, err := strconv.ParseFloat(params.Get("lat"), 64)
+	if err != nil {
+		server.BadRequest("invalid-lat", err, w, r)
+		return
+	}
+
+	lon, err := strconv.ParseFloat(params.Get("lon"), 64)
+	if err != nil {
+		server.BadRequest("invalid-lon", err, w, r)
+		return
+	}
+
+	k := defaultNearest
+	if rawK := params.Get("k"); rawK != "" {
+		k, err = strconv.Atoi(rawK)
+		if err != nil {
+			server.BadRequest("invalid-k", err, w, r)
+			return
+		}
+	}
+
+	ports, err := h.service.NearestPorts(r.Context(), lat, lon, k)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			server.BadRequest("invalid-query", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(nearbyPortsDomainToHttp(ports), w, r)
+}
+
+func (h HttpServer) PortsWithin(w http.ResponseWriter, r *http.Request) {
+	box, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		server.BadRequest("invalid-bbox", err, w, r)
+		return
+	}
+
+	ports, err := h.service.PortsWithin(r.Context(), box)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			server.BadRequest("invalid-query", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(nearbyPortsDomainToHttp(ports), w, r)
+}
+
+// SearchPorts answers ?q= with the ports whose name, alias, city or
+// province match it, most relevant first. Misspellings are tolerated.
+func (h HttpServer) SearchPorts(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	limit := 0
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			server.BadRequest("invalid-limit", err, w, r)
+			return
+		}
+	}
+
+	hits, err := h.service.SearchPorts(r.Context(), domain.SearchQuery{Text: params.Get("q"), Limit: limit})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			server.BadRequest("invalid-query", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(searchHitsDomainToHttp(hits), w, r)
+}
+
+// AutocompletePorts answers ?prefix= with the ports whose name or unloc
+// starts with it, for type-ahead.
+func (h HttpServer) AutocompletePorts(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	limit := 0
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			server.BadRequest("invalid-limit", err, w, r)
+			return
+		}
+	}
+
+	suggestions, err := h.service.AutocompletePorts(r.Context(), domain.AutocompleteQuery{Prefix: params.Get("prefix"), Limit: limit})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidQuery) {
+			server.BadRequest("invalid-query", err, w, r)
+			return
+		}
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	server.RespondOK(suggestionsDomainToHttp(suggestions), w, r)
+}
+
+// UploadPorts stores the ports of the body, or creates a single port when
+// the body is sent as application/vnd.port+json.
 func (h HttpServer) UploadPorts(w http.ResponseWriter, r *http.Request) {
+	if isSinglePortUpload(r) {
+		h.createPort(w, r)
+		return
+	}
+
+	opts, err := parseUploadOptions(r, h.validation)
+	if err != nil {
+		server.BadRequest("invalid-upload-options", err, w, r)
+		return
+	}
+
+	read, ok := h.portReader(w, r)
+	if !ok {
+		return
+	}
 
 	portChan := make(chan Port)
-	doneChan := make(chan struct{})
-	errChan := make(chan error)
+	doneChan := make(chan struct{}, 1)
+	errChan := make(chan error, 1)
 
 	go func() {
-		err := readPorts(r.Context(), r.Body, portChan)
+		err := read(r.Context(), r.Body, portChan)
 		if err != nil {
 			errChan <- err
 		} else {
@@ -89,6 +407,7 @@ func (h HttpServer) UploadPorts(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 	portCounter := 0
+	uploader := newPortUploader(h.service, opts)
 	for {
 		select {
 		case <-r.Context().Done():
@@ -96,28 +415,158 @@ func (h HttpServer) UploadPorts(w http.ResponseWriter, r *http.Request) {
 			return
 		case <-doneChan:
 			log.Info("finished reading ports")
-			server.RespondOK(map[string]int{"total_ports": portCounter}, w, r)
+			err := uploader.finish(r.Context())
+			if err != nil {
+				respondWithUploadError(err, w, r)
+				return
+			}
+			if !opts.report {
+				server.RespondOK(map[string]int{"total_ports": portCounter}, w, r)
+				return
+			}
+			if uploader.report.Rejected > 0 {
+				server.UnprocessableEntity("ports-rejected", fmt.Errorf("%d ports rejected", uploader.report.Rejected), uploader.report, w, r)
+				return
+			}
+			server.RespondOK(uploader.report, w, r)
 			return
 		case err := <-errChan:
 			log.Infof("error while parsing port json: %+v", err)
+			if opts.continueOnError {
+				server.BadRequestWithDetails("invalid json", err, uploader.report, w, r)
+				return
+			}
 			server.BadRequest("invalid json", err, w, r)
 			return
 		case port := <-portChan:
 			portCounter++
 			log.Infof("[%d] received port: %+v", portCounter, port)
-			p, err := portHttpToDomain(&port)
+			err := uploader.add(r.Context(), port)
 			if err != nil {
-				server.BadRequest("port-to-domain", err, w, r)
+				respondWithUploadError(err, w, r)
 				return
 			}
+		}
+	}
+}
 
-			err = h.service.CreateOrUpdatePort(r.Context(), p)
-			if err != nil {
-				server.RespondWithError(err, w, r)
-				return
-			}
+// portReader picks the reader for the upload format, answering the request
+// when the format is not supported.
+func (h HttpServer) portReader(w http.ResponseWriter, r *http.Request) (portReader, bool) {
+	read, err := newPortReader(r)
+	if errors.Is(err, errUnsupportedMediaType) {
+		server.UnsupportedMediaType("unsupported-media-type", err, w, r)
+		return nil, false
+	}
+	if err != nil {
+		server.BadRequest("invalid-format-options", err, w, r)
+		return nil, false
+	}
+
+	return read, true
+}
+
+func respondWithUploadError(err error, w http.ResponseWriter, r *http.Request) {
+	var fieldErr *domain.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		server.BadRequest("port-to-domain", err, w, r)
+	case errors.Is(err, domain.ErrAlreadyExists):
+		server.Conflict("port-already-exists", err, w, r)
+	case errors.Is(err, domain.ErrNotFound):
+		server.NotFound("port-not-found", err, w, r)
+	default:
+		server.RespondWithError(err, w, r)
+	}
+}
+
+func (h HttpServer) CreateUploadJob(w http.ResponseWriter, r *http.Request) {
+	validation, ok := h.validationMode(w, r)
+	if !ok {
+		return
+	}
+
+	read, ok := h.portReader(w, r)
+	if !ok {
+		return
+	}
+
+	document, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJobDocumentSize))
+	if err != nil {
+		server.BadRequest("could not read upload document", err, w, r)
+		return
+	}
+
+	job, err := h.jobs.start(h.service, read, document, validation)
+	if errors.Is(err, errJobsClosed) {
+		server.ServiceUnavailable("jobs-closed", err, w, r)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Retry-After", "60")
+		server.TooManyRequests("too-many-jobs", err, w, r)
+		return
+	}
+
+	w.Header().Set("Location", "/ports/jobs/"+job.id)
+	server.RespondAccepted(job.view(), w, r)
+}
+
+func (h HttpServer) GetUploadJob(w http.ResponseWriter, r *http.Request) {
+	job, exists := h.jobs.get(mux.Vars(r)["id"])
+	if !exists {
+		server.NotFound("job-not-found", nil, w, r)
+		return
+	}
+
+	server.RespondOK(job.view(), w, r)
+}
+
+func (h HttpServer) CancelUploadJob(w http.ResponseWriter, r *http.Request) {
+	job, exists := h.jobs.get(mux.Vars(r)["id"])
+	if !exists {
+		server.NotFound("job-not-found", nil, w, r)
+		return
+	}
+
+	job.cancel()
+
+	// the job stops at the next port, wait for it to settle its counts
+	select {
+	case <-job.done:
+	case <-r.Context().Done():
+		return
+	}
+
+	server.RespondOK(job.view(), w, r)
+}
+
+// RestorePort brings a deleted port back and answers with it.
+func (h HttpServer) RestorePort(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	err := h.service.RestorePort(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAlreadyExists):
+			server.Conflict("port-not-deleted", err, w, r)
+		case errors.Is(err, domain.ErrNotFound):
+			server.NotFound("deleted-port-not-found", err, w, r)
+		default:
+			server.RespondWithError(err, w, r)
 		}
+		return
 	}
+
+	record, err := h.service.GetPortRecord(ctx, id)
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	w.Header().Set("ETag", portETag(record.Version))
+	server.RespondOK(portDomainToHttp(record.Port), w, r)
 }
 
 func (h HttpServer) DeleteAllPorts(w http.ResponseWriter, r *http.Request) {
@@ -136,6 +585,8 @@ func (h HttpServer) DeleteAllPorts(w http.ResponseWriter, r *http.Request) {
 	server.RespondOK("all ports deleted successfully", w, r)
 }
 
+// DeletePortsById deletes the port. With If-Match or If-None-Match the port
+// is deleted only if it is still at a matching version when the delete runs.
 func (h HttpServer) DeletePortsById(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
@@ -144,15 +595,47 @@ func (h HttpServer) DeletePortsById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.DeletePortById(r.Context(), id)
+	var err error
+	if isConditional(r) {
+		err = h.deletePortIfMatches(r, id)
+	} else {
+		err = h.service.DeletePortById(r.Context(), id)
+	}
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
+		var slugErr commonerrors.SlugError
+		switch {
+		case errors.As(err, &slugErr):
+			server.RespondWithError(slugErr, w, r)
+		case errors.Is(err, domain.ErrNotFound):
 			server.NotFound("port not found", err, w, r)
-			return
+		default:
+			server.InternalError("could not delete port by id", err, w, r)
 		}
-		server.InternalError("could not delete port by id", err, w, r)
 		return
 	}
 
 	server.RespondOK(fmt.Sprintf("deleted port[%s] successfully", id), w, r)
 }
+
+func (h HttpServer) deletePortIfMatches(r *http.Request, id string) error {
+	record, err := h.service.GetPortRecord(r.Context(), id)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	err = checkWritePreconditions(r, record)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return domain.ErrNotFound
+	}
+
+	// the version checked above must still be the stored one when deleting
+	err = h.service.DeletePortIfVersion(r.Context(), id, record.Version)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		return commonerrors.NewPreconditionFailedError(err.Error(), slugPreconditionFailed)
+	}
+
+	return err
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,12 +9,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
 	"github.com/zhenisduissekov/another-dummy-service/internal/repository/inmem"
 	"github.com/zhenisduissekov/another-dummy-service/internal/services"
 )
@@ -30,7 +35,7 @@ func NewTestSuite() *HttpTestSuite {
 	portStore := inmem.NewPortStore()
 
 	s.portService = services.NewPortService(portStore)
-	s.httpServer = NewHttpServer(s.portService)
+	s.httpServer = NewHttpServer(s.portService, domain.ValidationLenient)
 
 	return s
 }
@@ -39,6 +44,11 @@ func TestHttpTestSuite(t *testing.T) {
 	suite.Run(t, NewTestSuite())
 }
 
+// SetupTest starts every test with an empty store.
+func (suite *HttpTestSuite) SetupTest() {
+	require.NoError(suite.T(), suite.portService.DeleteAllPorts(context.Background()))
+}
+
 func (suite *HttpTestSuite) TestUploadPorts_badJSON() {
 	// Load test fixtures
 	portsRequest := []byte(`blabla`)
@@ -123,3 +133,1407 @@ func countJSONPorts(t *testing.T, data []byte) int {
 
 	return len(ports)
 }
+
+func (suite *HttpTestSuite) TestListPorts() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+	_, res := suite.executeUploadPortsRequest(portsRequest)
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	seen := make(map[string]struct{})
+	cursor := ""
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/ports?country=United+Arab+Emirates&limit=2&cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		suite.httpServer.ListPorts(w, req)
+		require.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response struct {
+			Data PortList `json:"data"`
+		}
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+
+		for _, port := range response.Data.Ports {
+			require.Equal(suite.T(), "United Arab Emirates", port.Country)
+			seen[port.Id] = struct{}{}
+		}
+
+		if response.Data.NextCursor == "" {
+			break
+		}
+		cursor = response.Data.NextCursor
+	}
+
+	require.Contains(suite.T(), seen, "AEJEA")
+	require.Len(suite.T(), seen, countCountryPorts(suite.T(), portsRequest, "United Arab Emirates"))
+}
+
+func (suite *HttpTestSuite) TestListPorts_invalidLimit() {
+	req := httptest.NewRequest(http.MethodGet, "/ports?limit=many", nil)
+	w := httptest.NewRecorder()
+	suite.httpServer.ListPorts(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func countCountryPorts(t *testing.T, data []byte, country string) int {
+	t.Helper()
+	var ports map[string]Port
+	err := json.Unmarshal(data, &ports)
+	require.NoError(t, err)
+
+	count := 0
+	for _, port := range ports {
+		if port.Country == country {
+			count++
+		}
+	}
+	return count
+}
+
+func (suite *HttpTestSuite) TestGetPortByUnloc() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+	_, res := suite.executeUploadPortsRequest(portsRequest)
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	req := httptest.NewRequest(http.MethodGet, "/ports/by-unloc/aejea", nil)
+	req = mux.SetURLVars(req, map[string]string{"unloc": "aejea"})
+	w := httptest.NewRecorder()
+	suite.httpServer.GetPortByUnloc(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data Port `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), "AEJEA", response.Data.Id)
+	require.Equal(suite.T(), "Jebel Ali", response.Data.Name)
+
+	req = httptest.NewRequest(http.MethodGet, "/ports/by-unloc/XXXXX", nil)
+	req = mux.SetURLVars(req, map[string]string{"unloc": "XXXXX"})
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortByUnloc(w, req)
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *HttpTestSuite) TestNearestPorts() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+	_, res := suite.executeUploadPortsRequest(portsRequest)
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	req := httptest.NewRequest(http.MethodGet, "/ports/nearest?lat=25.26&lon=55.28&k=3", nil)
+	w := httptest.NewRecorder()
+	suite.httpServer.NearestPorts(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data []NearbyPort `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 3)
+	require.Equal(suite.T(), "AEDXB", response.Data[0].Port.Id)
+	require.Less(suite.T(), response.Data[0].DistanceKm, 2.0)
+
+	req = httptest.NewRequest(http.MethodGet, "/ports/within?bbox=55,25,56,26", nil)
+	w = httptest.NewRecorder()
+	suite.httpServer.PortsWithin(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(suite.T(), response.Data)
+}
+
+func (suite *HttpTestSuite) TestUploadJob() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+
+	req := httptest.NewRequest(http.MethodPost, "/ports/jobs", bytes.NewBuffer(portsRequest))
+	w := httptest.NewRecorder()
+	suite.httpServer.CreateUploadJob(w, req)
+	require.Equal(suite.T(), http.StatusAccepted, w.Code)
+
+	var created struct {
+		Data UploadJob `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(suite.T(), created.Data.Id)
+	require.Equal(suite.T(), "/ports/jobs/"+created.Data.Id, w.Header().Get("Location"))
+
+	job := suite.waitForJob(created.Data.Id)
+	require.Equal(suite.T(), JobStateCompleted, job.State)
+	require.Equal(suite.T(), countJSONPorts(suite.T(), portsRequest), job.Total)
+	require.Equal(suite.T(), job.Total, job.Processed)
+	require.Zero(suite.T(), job.Failed)
+	require.Zero(suite.T(), job.Skipped)
+
+	// cancelling a finished job leaves it as it is
+	req = httptest.NewRequest(http.MethodDelete, "/ports/jobs/"+job.Id, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": job.Id})
+	w = httptest.NewRecorder()
+	suite.httpServer.CancelUploadJob(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), JobStateCompleted, suite.getJob(job.Id).State)
+}
+
+func (suite *HttpTestSuite) TestUploadJob_recordErrors() {
+	document := []byte(`{"AAAAA":{"name":"A","city":"A","country":"A"},"BBBBB":{"name":"","city":"B","country":"B"},"CCCCC":{"name":"C","city":"C","country":"C"},`)
+
+	req := httptest.NewRequest(http.MethodPost, "/ports/jobs", bytes.NewBuffer(document))
+	w := httptest.NewRecorder()
+	suite.httpServer.CreateUploadJob(w, req)
+	require.Equal(suite.T(), http.StatusAccepted, w.Code)
+
+	var created struct {
+		Data UploadJob `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+
+	job := suite.waitForJob(created.Data.Id)
+	require.Equal(suite.T(), JobStateFailed, job.State)
+	require.Equal(suite.T(), 3, job.Total)
+	require.Equal(suite.T(), 2, job.Processed)
+	require.Equal(suite.T(), 1, job.Failed)
+	require.Len(suite.T(), job.Errors, 1)
+	require.Equal(suite.T(), "BBBBB", job.Errors[0].PortId)
+	require.NotEmpty(suite.T(), job.Error)
+}
+
+func (suite *HttpTestSuite) TestUploadJob_tooMany() {
+	httpServer := NewHttpServer(suite.portService, domain.ValidationLenient)
+	var running []*uploadJob
+	for i := 0; i < maxUnfinishedJobs; i++ {
+		job := &uploadJob{id: strconv.Itoa(i), state: JobStateRunning, done: make(chan struct{})}
+		httpServer.jobs.jobs[job.id] = job
+		httpServer.jobs.order = append(httpServer.jobs.order, job.id)
+		running = append(running, job)
+	}
+
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ports/jobs", bytes.NewBufferString(`{"AAAAA":{"name":"A","city":"A","country":"A"}}`))
+		w := httptest.NewRecorder()
+		httpServer.CreateUploadJob(w, req)
+		return w
+	}
+
+	w := create()
+	require.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	require.Contains(suite.T(), w.Body.String(), "too-many-jobs")
+	require.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+
+	running[0].finish(JobStateCompleted, nil)
+	w = create()
+	require.Equal(suite.T(), http.StatusAccepted, w.Code)
+
+	var created struct {
+		Data UploadJob `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+	job, exists := httpServer.jobs.get(created.Data.Id)
+	require.True(suite.T(), exists)
+	<-job.done
+}
+
+// blockingPortService stores no port, it waits for the store to be
+// cancelled.
+type blockingPortService struct {
+	PortService
+	storing chan struct{}
+}
+
+func (s blockingPortService) CreateOrUpdatePort(ctx context.Context, _ *domain.Port) error {
+	s.storing <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (suite *HttpTestSuite) TestUploadJob_closeJobs() {
+	service := blockingPortService{PortService: suite.portService, storing: make(chan struct{}, 1)}
+	httpServer := NewHttpServer(service, domain.ValidationLenient)
+
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ports/jobs", bytes.NewBufferString(`{"AAAAA":{"name":"A","city":"A","country":"A"}}`))
+		w := httptest.NewRecorder()
+		httpServer.CreateUploadJob(w, req)
+		return w
+	}
+
+	w := create()
+	require.Equal(suite.T(), http.StatusAccepted, w.Code)
+	var created struct {
+		Data UploadJob `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+	<-service.storing
+
+	// the running job is cancelled, and finished once CloseJobs returns
+	httpServer.CloseJobs()
+	job, exists := httpServer.jobs.get(created.Data.Id)
+	require.True(suite.T(), exists)
+	require.True(suite.T(), job.finished())
+	require.Equal(suite.T(), JobStateCancelled, job.view().State)
+
+	w = create()
+	require.Equal(suite.T(), http.StatusServiceUnavailable, w.Code)
+	require.Contains(suite.T(), w.Body.String(), "jobs-closed")
+
+	// closing again is harmless
+	httpServer.CloseJobs()
+}
+
+func (suite *HttpTestSuite) TestUploadJob_notFound() {
+	req := httptest.NewRequest(http.MethodGet, "/ports/jobs/unknown", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "unknown"})
+	w := httptest.NewRecorder()
+	suite.httpServer.GetUploadJob(w, req)
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *HttpTestSuite) getJob(id string) UploadJob {
+	req := httptest.NewRequest(http.MethodGet, "/ports/jobs/"+id, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	suite.httpServer.GetUploadJob(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data UploadJob `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Data
+}
+
+func (suite *HttpTestSuite) waitForJob(id string) UploadJob {
+	var job UploadJob
+	require.Eventually(suite.T(), func() bool {
+		job = suite.getJob(id)
+		return job.FinishedAt != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_continueOnError() {
+	document := []byte(`{"AAAAA":{"name":"A","city":"A","country":"Netherlands"},"BBBBB":{"name":"B","city":"","country":"Netherlands"},"CCCCC":{"name":"C","city":"C","country":"Netherlands"}}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/ports?onError=continue", bytes.NewBuffer(document))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var response struct {
+		Slug    string       `json:"slug"`
+		Details uploadReport `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), "ports-rejected", response.Slug)
+	require.Equal(suite.T(), uploadReport{
+		TotalPorts: 3,
+		Accepted:   2,
+		Rejected:   1,
+		Created:    2,
+		Errors: []RecordError{{
+			PortId: "BBBBB",
+			Field:  "city",
+			Cause:  "required value",
+			Error:  "required value: port city is required",
+		}},
+	}, response.Details)
+
+	count, err := suite.portService.CountPorts(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, count)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_invalidCoordinates() {
+	document := []byte(`{"AAAAA":{"name":"A","city":"A","country":"A","coordinates":[55.5]},"BBBBB":{"name":"B","city":"B","country":"B","coordinates":[55.5,25.4]}}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/ports?onError=continue", bytes.NewBuffer(document))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var response struct {
+		Details uploadReport `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), []RecordError{{
+		PortId: "AAAAA",
+		Field:  "coordinates",
+		Cause:  "invalid coordinates",
+		Error:  "invalid coordinates: expected [longitude, latitude], got 1 values",
+	}}, response.Details.Errors)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_validation() {
+	document := `{"AEAJM":{"name":"Ajman","city":"Ajman","country":"United Arab Emirates","timezone":"Asia/Dubia","unlocs":["AEAJM"]},"AEDXB":{"name":"Dubai","city":"Dubai","country":"United Arab Emirates","timezone":"Asia/Dubai","unlocs":["AEDXB"]}}`
+
+	upload := func(target string) (*httptest.ResponseRecorder, uploadReport) {
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(document))
+		w := httptest.NewRecorder()
+		suite.httpServer.UploadPorts(w, req)
+
+		var response struct {
+			Data    uploadReport `json:"data"`
+			Details uploadReport `json:"details"`
+		}
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		if w.Code == http.StatusOK {
+			return w, response.Data
+		}
+		return w, response.Details
+	}
+
+	w, report := upload("/ports?onError=continue")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.Equal(suite.T(), 2, report.Accepted)
+	require.Equal(suite.T(), []RecordError{{
+		PortId: "AEAJM",
+		Field:  "timezone",
+		Cause:  "unknown timezone",
+		Error:  "unknown timezone: port timezone Asia/Dubia is not an IANA timezone",
+	}}, report.Warnings)
+
+	w, report = upload("/ports?onError=continue&validation=strict")
+	require.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	require.Equal(suite.T(), 1, report.Accepted)
+	require.Equal(suite.T(), 1, report.Rejected)
+	require.Equal(suite.T(), "timezone", report.Errors[0].Field)
+
+	w, _ = upload("/ports?validation=pedantic")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = suite.executePortRequest(suite.httpServer.ReplacePort, http.MethodPut, "AEAJM", mediaTypeJSON, `{"name":"Ajman","city":"Ajman","country":"Untied Arab Emirates"}`)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	req := httptest.NewRequest(http.MethodPut, "/ports/AEAJM?validation=strict", bytes.NewBufferString(`{"name":"Ajman","city":"Ajman","country":"Untied Arab Emirates"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": "AEAJM"})
+	w = httptest.NewRecorder()
+	suite.httpServer.ReplacePort(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_everyFailingField() {
+	req := httptest.NewRequest(http.MethodPost, "/ports?onError=continue", bytes.NewBufferString(`{"AAAAA":{"country":"Netherlands"}}`))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var response struct {
+		Details uploadReport `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), 1, response.Details.Rejected)
+	require.Equal(suite.T(), []RecordError{
+		{PortId: "AAAAA", Field: "name", Cause: "required value", Error: "required value: port name is required"},
+		{PortId: "AAAAA", Field: "city", Cause: "required value", Error: "required value: port city is required"},
+	}, response.Details.Errors)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_invalidOnError() {
+	req := httptest.NewRequest(http.MethodPost, "/ports?onError=ignore", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_atomic() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+
+	// cut the document half way through
+	req := httptest.NewRequest(http.MethodPost, "/ports?atomic=true", bytes.NewBuffer(portsRequest[:len(portsRequest)/2]))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	count, err := suite.portService.CountPorts(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), count)
+
+	req = httptest.NewRequest(http.MethodPost, "/ports?atomic=true", bytes.NewBuffer(portsRequest))
+	w = httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	suite.validateStoredPorts(portsRequest)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_atomicRejectedPort() {
+	document := []byte(`{"AAAAA":{"name":"A","city":"A","country":"A"},"BBBBB":{"name":"B","city":"","country":"B"}}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/ports?atomic=true", bytes.NewBuffer(document))
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	count, err := suite.portService.CountPorts(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), count)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_strategies() {
+	initial := []byte(`{"AAAAA":{"name":"A","city":"A","country":"Netherlands"},"BBBBB":{"name":"B","city":"B","country":"Netherlands"},"CCCCC":{"name":"C","city":"C","country":"Netherlands"}}`)
+	update := []byte(`{"AAAAA":{"name":"A","city":"A","country":"Netherlands"},"BBBBB":{"name":"B2","city":"B","country":"Netherlands"},"DDDDD":{"name":"D","city":"D","country":"Netherlands"}}`)
+
+	tests := []struct {
+		strategy string
+		status   int
+		want     uploadReport
+		stored   []string
+	}{
+		{
+			strategy: "upsert",
+			status:   http.StatusOK,
+			want:     uploadReport{TotalPorts: 3, Accepted: 3, Created: 1, Updated: 1, Unchanged: 1},
+			stored:   []string{"AAAAA", "BBBBB", "CCCCC", "DDDDD"},
+		},
+		{
+			strategy: "insert-only",
+			status:   http.StatusOK,
+			want:     uploadReport{TotalPorts: 3, Accepted: 3, Created: 1, Skipped: 2},
+			stored:   []string{"AAAAA", "BBBBB", "CCCCC", "DDDDD"},
+		},
+		{
+			strategy: "update-only",
+			status:   http.StatusOK,
+			want:     uploadReport{TotalPorts: 3, Accepted: 3, Updated: 1, Unchanged: 1, Skipped: 1},
+			stored:   []string{"AAAAA", "BBBBB", "CCCCC"},
+		},
+		{
+			strategy: "replace",
+			status:   http.StatusOK,
+			want:     uploadReport{TotalPorts: 3, Accepted: 3, Created: 1, Updated: 1, Unchanged: 1, Deleted: 1},
+			stored:   []string{"AAAAA", "BBBBB", "DDDDD"},
+		},
+		{
+			strategy: "replace&atomic=true",
+			status:   http.StatusOK,
+			want:     uploadReport{TotalPorts: 3, Accepted: 3, Created: 1, Updated: 1, Unchanged: 1, Deleted: 1},
+			stored:   []string{"AAAAA", "BBBBB", "DDDDD"},
+		},
+		{
+			strategy: "insert-only&onConflict=reject",
+			status:   http.StatusConflict,
+			stored:   []string{"AAAAA", "BBBBB", "CCCCC"},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.strategy, func() {
+			suite.SetupTest()
+			_, res := suite.executeUploadPortsRequest(initial)
+			require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+			req := httptest.NewRequest(http.MethodPost, "/ports?strategy="+tt.strategy, bytes.NewBuffer(update))
+			w := httptest.NewRecorder()
+			suite.httpServer.UploadPorts(w, req)
+			require.Equal(suite.T(), tt.status, w.Code)
+
+			if tt.status == http.StatusOK {
+				var response struct {
+					Data uploadReport `json:"data"`
+				}
+				require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+				tt.want.Errors = []RecordError{}
+				require.Equal(suite.T(), tt.want, response.Data)
+			}
+
+			page, err := suite.portService.ListPorts(context.Background(), domain.PortQuery{})
+			require.NoError(suite.T(), err)
+			ids := make([]string, 0, len(page.Ports))
+			for _, port := range page.Ports {
+				ids = append(ids, port.Id())
+			}
+			require.Equal(suite.T(), tt.stored, ids)
+		})
+	}
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_repeatedId() {
+	document := `{"id":"EEEEE","name":"E","city":"E","country":"Netherlands"}
+{"id":"EEEEE","name":"E2","city":"E","country":"Netherlands"}
+{"id":"FFFFF","name":"F","city":"F","country":"Netherlands"}
+{"id":"FFFFF","name":"F","city":"F","country":"Netherlands"}
+`
+
+	tests := []struct {
+		strategy string
+		want     uploadReport
+		name     string
+	}{
+		{
+			strategy: "upsert",
+			want:     uploadReport{TotalPorts: 4, Accepted: 4, Created: 2, Updated: 1, Unchanged: 1},
+			name:     "E2",
+		},
+		{
+			strategy: "upsert&atomic=true",
+			want:     uploadReport{TotalPorts: 4, Accepted: 4, Created: 2, Updated: 1, Unchanged: 1},
+			name:     "E2",
+		},
+		{
+			strategy: "insert-only",
+			want:     uploadReport{TotalPorts: 4, Accepted: 4, Created: 2, Skipped: 2},
+			name:     "E",
+		},
+		{
+			strategy: "insert-only&atomic=true",
+			want:     uploadReport{TotalPorts: 4, Accepted: 4, Created: 2, Skipped: 2},
+			name:     "E",
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.strategy, func() {
+			suite.SetupTest()
+
+			req := httptest.NewRequest(http.MethodPost, "/ports?strategy="+tt.strategy, bytes.NewBufferString(document))
+			req.Header.Set("Content-Type", mediaTypeNDJSON)
+			w := httptest.NewRecorder()
+			suite.httpServer.UploadPorts(w, req)
+			require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+			var response struct {
+				Data uploadReport `json:"data"`
+			}
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+			tt.want.Errors = []RecordError{}
+			require.Equal(suite.T(), tt.want, response.Data)
+
+			port, err := suite.portService.GetPort(context.Background(), "EEEEE")
+			require.NoError(suite.T(), err)
+			require.Equal(suite.T(), tt.name, port.Name())
+		})
+	}
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_atomicInsertOnlyConflict() {
+	// a port created after the upload staged its insert fails the batch
+	uploader := newPortUploader(suite.portService, uploadOptions{atomic: true, strategy: strategyInsertOnly, report: true})
+	port, err := domain.NewPort("EEEEE", "E", "", "E", "Netherlands", nil, nil, nil, "", "", nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), uploader.add(context.Background(), Port{Id: "EEEEE", Name: "E2", City: "E", Country: "Netherlands"}))
+
+	require.NoError(suite.T(), suite.portService.CreatePort(context.Background(), port))
+	err = uploader.finish(context.Background())
+	require.ErrorIs(suite.T(), err, domain.ErrAlreadyExists)
+
+	stored, err := suite.portService.GetPort(context.Background(), "EEEEE")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "E", stored.Name())
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_formats() {
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		document    string
+	}{
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			document: `{"id":"AEAJM","name":"Ajman","city":"Ajman","country":"United Arab Emirates","coordinates":[55.5136433,25.4052165],"unlocs":["AEAJM"]}
+{"id":"AEAUH","name":"Abu Dhabi","city":"Abu Dhabi","country":"United Arab Emirates","alias":["Abu Zaby"],"unlocs":["AEAUH"]}
+`,
+		},
+		{
+			name:        "csv",
+			contentType: "text/csv; charset=utf-8",
+			document: `id,name,city,country,coordinates,alias,unlocs
+AEAJM,Ajman,Ajman,United Arab Emirates,55.5136433|25.4052165,,AEAJM
+AEAUH,Abu Dhabi,Abu Dhabi,United Arab Emirates,,Abu Zaby,AEAUH
+`,
+		},
+		{
+			name:        "csv with column mapping",
+			contentType: "text/csv",
+			query:       "?columns=id:locode,alias:aliases,longitude:lon,latitude:lat&listSeparator=;",
+			document: `locode,name,city,country,lon,lat,aliases
+AEAJM,Ajman,Ajman,United Arab Emirates,55.5136433,25.4052165,
+AEAUH,Abu Dhabi,Abu Dhabi,United Arab Emirates,,,Abu Zaby
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+
+			req := httptest.NewRequest(http.MethodPost, "/ports"+tt.query, bytes.NewBufferString(tt.document))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			suite.httpServer.UploadPorts(w, req)
+			require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+			ajman, err := suite.portService.GetPort(context.Background(), "AEAJM")
+			require.NoError(suite.T(), err)
+			require.Equal(suite.T(), []float64{55.5136433, 25.4052165}, ajman.Coordinates())
+
+			abuDhabi, err := suite.portService.GetPort(context.Background(), "AEAUH")
+			require.NoError(suite.T(), err)
+			require.Equal(suite.T(), []string{"Abu Zaby"}, abuDhabi.Alias())
+		})
+	}
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_unsupportedMediaType() {
+	req := httptest.NewRequest(http.MethodPost, "/ports", bytes.NewBufferString(`<ports/>`))
+	req.Header.Set("Content-Type", "application/xml")
+	w := httptest.NewRecorder()
+	suite.httpServer.UploadPorts(w, req)
+	require.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+}
+
+func (suite *HttpTestSuite) TestExportPorts_roundTrip() {
+	_, res := suite.executeUploadPortsRequest(suite.loadFixture("testfixtures/ports_request.json"))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	var uploaded []*domain.Port
+	err := suite.portService.ForEachPort(context.Background(), func(port *domain.Port) error {
+		uploaded = append(uploaded, port)
+		return nil
+	})
+	require.NoError(suite.T(), err)
+
+	tests := []struct {
+		format      string
+		contentType string
+	}{
+		{format: "json", contentType: "application/json"},
+		{format: "ndjson", contentType: "application/x-ndjson"},
+		{format: "csv", contentType: "text/csv"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.format, func() {
+			req := httptest.NewRequest(http.MethodGet, "/ports/export?format="+tt.format, nil)
+			w := httptest.NewRecorder()
+			suite.httpServer.ExportPorts(w, req)
+			require.Equal(suite.T(), http.StatusOK, w.Code)
+			require.Equal(suite.T(), tt.contentType, w.Header().Get("Content-Type"))
+			require.Contains(suite.T(), w.Header().Get("Content-Disposition"), "ports."+tt.format)
+
+			require.NoError(suite.T(), suite.portService.DeleteAllPorts(context.Background()))
+
+			req = httptest.NewRequest(http.MethodPost, "/ports", w.Body)
+			req.Header.Set("Content-Type", tt.contentType)
+			w = httptest.NewRecorder()
+			suite.httpServer.UploadPorts(w, req)
+			require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+			count, err := suite.portService.CountPorts(context.Background())
+			require.NoError(suite.T(), err)
+			require.Equal(suite.T(), len(uploaded), count)
+
+			for _, expected := range uploaded {
+				port, err := suite.portService.GetPort(context.Background(), expected.Id())
+				require.NoError(suite.T(), err)
+				require.True(suite.T(), expected.Equal(port), "port %s changed in the round trip", expected.Id())
+			}
+		})
+	}
+}
+
+func (suite *HttpTestSuite) TestExportPorts_geoJSON() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{
+		"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates","coordinates":[55.5136433,25.4052165]},
+		"AEAUH": {"name":"Abu Dhabi","city":"Abu Dhabi","country":"United Arab Emirates"}
+	}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	req := httptest.NewRequest(http.MethodGet, "/ports/export", nil)
+	req.Header.Set("Accept", "application/geo+json")
+	w := httptest.NewRecorder()
+	suite.httpServer.ExportPorts(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), "application/geo+json", w.Header().Get("Content-Type"))
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Id       string `json:"id"`
+			Geometry *struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties Port `json:"properties"`
+		} `json:"features"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &collection))
+	require.Equal(suite.T(), "FeatureCollection", collection.Type)
+	require.Len(suite.T(), collection.Features, 2)
+
+	ajman := collection.Features[0]
+	require.Equal(suite.T(), "AEAJM", ajman.Id)
+	require.Equal(suite.T(), "Point", ajman.Geometry.Type)
+	require.Equal(suite.T(), []float64{55.5136433, 25.4052165}, ajman.Geometry.Coordinates)
+	require.Equal(suite.T(), "Ajman", ajman.Properties.Name)
+
+	abuDhabi := collection.Features[1]
+	require.Equal(suite.T(), "AEAUH", abuDhabi.Id)
+	require.Nil(suite.T(), abuDhabi.Geometry)
+}
+
+func (suite *HttpTestSuite) TestExportPorts_notAcceptable() {
+	req := httptest.NewRequest(http.MethodGet, "/ports/export", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	suite.httpServer.ExportPorts(w, req)
+	require.Equal(suite.T(), http.StatusNotAcceptable, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/ports/export?format=xlsx", nil)
+	w = httptest.NewRecorder()
+	suite.httpServer.ExportPorts(w, req)
+	require.Equal(suite.T(), http.StatusNotAcceptable, w.Code)
+}
+
+func (suite *HttpTestSuite) TestGetPort_eTag() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	w := httptest.NewRecorder()
+	suite.httpServer.GetPort(w, httptest.NewRequest(http.MethodGet, "/port?id=AEAJM", nil))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(suite.T(), etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/port?id=AEAJM", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPort(w, req)
+	require.Equal(suite.T(), http.StatusNotModified, w.Code)
+	require.Empty(suite.T(), w.Body.String())
+
+	// an update gives the port a new version
+	_, res = suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman Port","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPort(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+}
+
+func (suite *HttpTestSuite) TestDeletePortsById_ifMatch() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	w := httptest.NewRecorder()
+	suite.httpServer.GetPort(w, httptest.NewRequest(http.MethodGet, "/port?id=AEAJM", nil))
+	etag := w.Header().Get("ETag")
+
+	deletePort := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/ports/AEAJM", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "AEAJM"})
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		suite.httpServer.DeletePortsById(w, req)
+		return w
+	}
+
+	w = deletePort("If-Match", `"0"`)
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	var errResponse server.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errResponse))
+	require.Equal(suite.T(), "port-precondition-failed", errResponse.Slug)
+
+	w = deletePort("If-None-Match", "*")
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w = deletePort("If-Match", etag)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = deletePort("If-Match", etag)
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+}
+
+func (suite *HttpTestSuite) TestGetPortHistory() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+	beforeUpdate := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+
+	_, res = suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman Port","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	getPort := func(target string) (*httptest.ResponseRecorder, Port) {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, target, nil), map[string]string{"id": "AEAJM"})
+		w := httptest.NewRecorder()
+		suite.httpServer.GetPortById(w, req)
+
+		var response struct {
+			Data Port `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response.Data
+	}
+
+	w, port := getPort("/ports/AEAJM")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), "Ajman Port", port.Name)
+
+	w, port = getPort("/ports/AEAJM?asOf=" + beforeUpdate.Format(time.RFC3339Nano))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), "Ajman", port.Name)
+
+	w, _ = getPort("/ports/AEAJM?asOf=" + beforeUpdate.Add(-time.Hour).Format(time.RFC3339))
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w, _ = getPort("/ports/AEAJM?asOf=yesterday")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/ports/AEAJM/history", nil), map[string]string{"id": "AEAJM"})
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortHistory(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data []PortRevision `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+
+	// the store outlives the tests of the suite, so earlier tests left revisions too
+	history := response.Data
+	require.GreaterOrEqual(suite.T(), len(history), 2)
+	require.Equal(suite.T(), "Ajman", history[len(history)-2].Port.Name)
+	require.Equal(suite.T(), "Ajman Port", history[len(history)-1].Port.Name)
+}
+
+func (suite *HttpTestSuite) TestGetPortHistory_purged() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+	beforeDelete := time.Now().UTC()
+	time.Sleep(time.Millisecond)
+
+	portRequest := func(method, target string) *http.Request {
+		return mux.SetURLVars(httptest.NewRequest(method, target, nil), map[string]string{"id": "AEAJM"})
+	}
+
+	w := httptest.NewRecorder()
+	suite.httpServer.DeletePortsById(w, portRequest(http.MethodDelete, "/ports/AEAJM"))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	purged, err := suite.portService.PurgeDeletedPorts(context.Background(), time.Now())
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), purged, "AEAJM")
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortHistory(w, portRequest(http.MethodGet, "/ports/AEAJM/history"))
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortById(w, portRequest(http.MethodGet, "/ports/AEAJM?asOf="+beforeDelete.Format(time.RFC3339Nano)))
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *HttpTestSuite) TestDeletePortsById_restore() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	portRequest := func(method, target string) *http.Request {
+		return mux.SetURLVars(httptest.NewRequest(method, target, nil), map[string]string{"id": "AEAJM"})
+	}
+
+	w := httptest.NewRecorder()
+	suite.httpServer.DeletePortsById(w, portRequest(http.MethodDelete, "/ports/AEAJM"))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortById(w, portRequest(http.MethodGet, "/ports/AEAJM"))
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortById(w, portRequest(http.MethodGet, "/ports/AEAJM?includeDeleted=true"))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Data Port `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), "Ajman", response.Data.Name)
+	require.NotEmpty(suite.T(), response.Data.DeletedAt)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.RestorePort(w, portRequest(http.MethodPost, "/ports/AEAJM/restore"))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotEmpty(suite.T(), w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	suite.httpServer.RestorePort(w, portRequest(http.MethodPost, "/ports/AEAJM/restore"))
+	require.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	suite.httpServer.GetPortById(w, portRequest(http.MethodGet, "/ports/AEAJM"))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *HttpTestSuite) TestUploadPorts_singlePort() {
+	create := func(contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ports", bytes.NewBufferString(`{"id":"AEAJM","name":"Ajman","city":"Ajman","country":"United Arab Emirates"}`))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		suite.httpServer.UploadPorts(w, req)
+		return w
+	}
+
+	// a single port sent as plain JSON is read as ports keyed by id
+	w := create(mediaTypeJSON)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+	count, err := suite.portService.CountPorts(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), count)
+
+	w = create(mediaTypePort + "; charset=utf-8")
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(suite.T(), "/ports/AEAJM", w.Header().Get("Location"))
+	require.NotEmpty(suite.T(), w.Header().Get("ETag"))
+
+	var response struct {
+		Data Port `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(suite.T(), "Ajman", response.Data.Name)
+
+	w = create(mediaTypePort)
+	require.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *HttpTestSuite) executePortRequest(handler http.HandlerFunc, method, id, contentType, body string, headers ...string) (*httptest.ResponseRecorder, Port) {
+	req := httptest.NewRequest(method, "/ports/"+id, bytes.NewBufferString(body))
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	req.Header.Set("Content-Type", contentType)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response struct {
+		Data Port `json:"data"`
+	}
+	if w.Code < http.StatusMultipleChoices {
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response.Data
+}
+
+func (suite *HttpTestSuite) TestReplacePort() {
+	put := func(id, body string, headers ...string) (*httptest.ResponseRecorder, Port) {
+		return suite.executePortRequest(suite.httpServer.ReplacePort, http.MethodPut, id, mediaTypeJSON, body, headers...)
+	}
+
+	w, port := put("AEAJM", `{"name":"Ajman","city":"Ajman","country":"United Arab Emirates","province":"Ajman"}`)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(suite.T(), "/ports/AEAJM", w.Header().Get("Location"))
+	require.Equal(suite.T(), "AEAJM", port.Id)
+	etag := w.Header().Get("ETag")
+
+	// a replace drops what the new port leaves out
+	w, port = put("AEAJM", `{"id":"AEAJM","name":"Ajman Port","city":"Ajman","country":"United Arab Emirates"}`, "If-Match", etag)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.Equal(suite.T(), "Ajman Port", port.Name)
+	require.Empty(suite.T(), port.Province)
+	require.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+
+	w, _ = put("AEAJM", `{"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}`, "If-Match", etag)
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w, _ = put("AEAJM", `{"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}`, "If-None-Match", "*")
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w, _ = put("AEAJM", `{"id":"AEDXB","name":"Dubai","city":"Dubai","country":"United Arab Emirates"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = put("AEAJM", `{"city":"Ajman","country":"United Arab Emirates"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *HttpTestSuite) TestPatchPort() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates","province":"Ajman","alias":["Ajman Port"]}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	patch := func(contentType, body string, headers ...string) (*httptest.ResponseRecorder, Port) {
+		return suite.executePortRequest(suite.httpServer.PatchPort, http.MethodPatch, "AEAJM", contentType, body, headers...)
+	}
+
+	var events []domain.Event
+	suite.portService.(services.PortService).Subscribe(func(_ context.Context, event domain.Event) {
+		events = append(events, event)
+	})
+
+	w, port := patch(mediaTypeMergePatch, `{"name":"Ajman Port","province":null}`)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.Equal(suite.T(), "Ajman Port", port.Name)
+	require.Equal(suite.T(), []domain.Event{domain.PortRenamed{Id: "AEAJM", OldName: "Ajman", NewName: "Ajman Port"}}, events)
+	require.Empty(suite.T(), port.Province)
+	require.Equal(suite.T(), "Ajman", port.City)
+	require.Equal(suite.T(), []string{"Ajman Port"}, port.Alias)
+	etag := w.Header().Get("ETag")
+
+	w, _ = patch(mediaTypeMergePatch, `{"name":null}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = patch(mediaTypeMergePatch, `{"id":"AEDXB"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = patch(mediaTypeMergePatch, `{"name":"Ajman"}`, "If-Match", `"0"`)
+	require.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+
+	w, port = patch(mediaTypeMergePatch, `{"name":"Ajman"}`, "If-Match", etag)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.Equal(suite.T(), "Ajman", port.Name)
+
+	w, _ = patch(mediaTypeCSV, `name`)
+	require.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+
+	w, _ = suite.executePortRequest(suite.httpServer.PatchPort, http.MethodPatch, "AEDXB", mediaTypeMergePatch, `{"name":"Dubai"}`)
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *HttpTestSuite) TestGetPort_deprecatedAlias() {
+	_, res := suite.executeUploadPortsRequest([]byte(`{"AEAJM": {"name":"Ajman","city":"Ajman","country":"United Arab Emirates"}}`))
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	w := httptest.NewRecorder()
+	suite.httpServer.GetPort(w, httptest.NewRequest(http.MethodGet, "/port?id=AEAJM", nil))
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotEmpty(suite.T(), w.Header().Get("Deprecation"))
+	require.Equal(suite.T(), `</ports/AEAJM>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples of RFC 7386, appendix A
+	cases := []struct {
+		target, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		var target, patch any
+		require.NoError(t, json.Unmarshal([]byte(c.target), &target))
+		require.NoError(t, json.Unmarshal([]byte(c.patch), &patch))
+
+		result, err := json.Marshal(mergePatch(target, patch))
+		require.NoError(t, err)
+		require.JSONEq(t, c.result, string(result), "%s patched with %s", c.target, c.patch)
+	}
+}
+
+func (suite *HttpTestSuite) TestSearchPorts() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+	_, res := suite.executeUploadPortsRequest(portsRequest)
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	search := func(target string) (*httptest.ResponseRecorder, []SearchHit) {
+		w := httptest.NewRecorder()
+		suite.httpServer.SearchPorts(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var response struct {
+			Data []SearchHit `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response.Data
+	}
+
+	w, hits := search("/ports/search?q=Abu+Dabi")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), "AEAUH", hits[0].Port.Id)
+
+	_, hits = search("/ports/search?q=Jebel+Ali&limit=3")
+	require.Equal(suite.T(), "AEJEA", hits[0].Port.Id)
+	require.LessOrEqual(suite.T(), len(hits), 3)
+
+	w, _ = search("/ports/search?q=")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = search("/ports/search?q=Ajman&limit=many")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *HttpTestSuite) TestAutocompletePorts() {
+	portsRequest := suite.loadFixture("testfixtures/ports_request.json")
+	_, res := suite.executeUploadPortsRequest(portsRequest)
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+
+	autocomplete := func(target string) (*httptest.ResponseRecorder, []Suggestion) {
+		w := httptest.NewRecorder()
+		suite.httpServer.AutocompletePorts(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var response struct {
+			Data []Suggestion `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response.Data
+	}
+
+	w, suggestions := autocomplete("/ports/autocomplete?prefix=ajm")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Equal(suite.T(), []Suggestion{{Id: "AEAJM", Name: "Ajman", Field: "name", Value: "Ajman"}}, suggestions)
+
+	_, suggestions = autocomplete("/ports/autocomplete?prefix=AEJ")
+	require.Equal(suite.T(), []Suggestion{
+		{Id: "AEJEA", Name: "Jebel Ali", Field: "unloc", Value: "AEJEA"},
+		{Id: "AEJED", Name: "Jebel Dhanna", Field: "unloc", Value: "AEJED"},
+	}, suggestions)
+
+	_, suggestions = autocomplete("/ports/autocomplete?prefix=ae&limit=3")
+	require.Len(suite.T(), suggestions, 3)
+
+	w, _ = autocomplete("/ports/autocomplete?prefix=")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, _ = autocomplete("/ports/autocomplete?prefix=ae&limit=-1")
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *HttpTestSuite) TestStreamPortChanges() {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(suite.httpServer.StreamPortChanges))
+	// cleanups run last to first, the streams are cancelled before this
+	suite.T().Cleanup(srv.Close)
+
+	stream := func(lastEventId string) (*http.Response, *bufio.Reader) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		suite.T().Cleanup(cancel)
+
+		req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, srv.URL, nil)
+		require.NoError(suite.T(), err)
+		if lastEventId != "" {
+			req.Header.Set("Last-Event-ID", lastEventId)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(suite.T(), err)
+		suite.T().Cleanup(func() { _ = res.Body.Close() })
+
+		return res, bufio.NewReader(res.Body)
+	}
+
+	res, events := stream("")
+	require.Equal(suite.T(), http.StatusOK, res.StatusCode)
+	require.Equal(suite.T(), "text/event-stream", res.Header.Get("Content-Type"))
+
+	port, err := domain.NewPort("AEAJM", "Ajman", "52000", "Ajman", "United Arab Emirates", nil, nil, nil, "Ajman", "Asia/Dubai", []string{"AEAJM"})
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.portService.CreateOrUpdatePort(ctx, port))
+	require.NoError(suite.T(), suite.portService.DeletePortById(ctx, "AEAJM"))
+
+	created := readChangeEvent(suite.T(), events)
+	require.Equal(suite.T(), "created", created.name)
+	require.Equal(suite.T(), "AEAJM", created.change.Id)
+	require.Equal(suite.T(), "Ajman", created.change.Port.Name)
+	require.Equal(suite.T(), created.id, strconv.FormatUint(created.change.Sequence, 10))
+
+	deleted := readChangeEvent(suite.T(), events)
+	require.Equal(suite.T(), "deleted", deleted.name)
+	require.Equal(suite.T(), created.change.Sequence+1, deleted.change.Sequence)
+	require.Equal(suite.T(), "Ajman", deleted.change.Port.Name)
+
+	// resuming replays the changes after the last one seen
+	_, events = stream(created.id)
+	resumed := readChangeEvent(suite.T(), events)
+	require.Equal(suite.T(), deleted, resumed)
+
+	// changes that are not kept any more cannot be replayed
+	_, events = stream(strconv.FormatUint(deleted.change.Sequence+100, 10))
+	reset := readChangeEvent(suite.T(), events)
+	require.Equal(suite.T(), "reset", reset.name)
+	require.Equal(suite.T(), deleted.id, reset.id)
+
+	res, _ = stream("last")
+	require.Equal(suite.T(), http.StatusBadRequest, res.StatusCode)
+}
+
+type changeEvent struct {
+	id     string
+	name   string
+	change PortChange
+}
+
+// readChangeEvent reads the next Server-Sent Event of a change stream.
+func readChangeEvent(t *testing.T, events *bufio.Reader) changeEvent {
+	t.Helper()
+
+	var event changeEvent
+	for {
+		line, err := events.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.name = value
+		case "data":
+			require.NoError(t, json.Unmarshal([]byte(value), &event.change))
+		}
+	}
+}
+
+func (suite *HttpTestSuite) TestWebhooks() {
+	ctx := context.Background()
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	delivered := make(chan received, 16)
+	attempts := make(chan struct{}, 64)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failing" {
+			attempts <- struct{}{}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(suite.T(), err)
+		delivered <- received{header: r.Header, body: body}
+	}))
+	defer receiver.Close()
+
+	webhooks := services.NewWebhooks(suite.portService.(services.PortService), EncodePortChange, receiver.Client(), services.RetryPolicy{
+		Attempts:   3,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	})
+	webhooks.Start()
+	defer webhooks.Close()
+	webhookServer := NewWebhookServer(webhooks)
+
+	execute := func(handler http.HandlerFunc, method, target, id, body string) (*httptest.ResponseRecorder, json.RawMessage) {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if id != "" {
+			req = mux.SetURLVars(req, map[string]string{"id": id})
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var response struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if w.Code < http.StatusBadRequest {
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response.Data
+	}
+	register := func(body string) (*httptest.ResponseRecorder, Webhook) {
+		w, data := execute(webhookServer.CreateWebhook, http.MethodPost, "/webhooks", "", body)
+
+		var webhook Webhook
+		if w.Code == http.StatusCreated {
+			require.NoError(suite.T(), json.Unmarshal(data, &webhook))
+		}
+		return w, webhook
+	}
+
+	w, _ := register(`{"url": "ftp://partner.example.com"}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w, _ = register(`{"url": "` + receiver.URL + `", "events": ["renamed"]}`)
+	require.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, filtered := register(`{"url": "` + receiver.URL + `/ok", "events": ["created", "deleted"], "countries": ["united arab emirates"]}`)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	require.NotEmpty(suite.T(), filtered.Secret)
+	w, failing := register(`{"url": "` + receiver.URL + `/failing", "secret": "s3cret"}`)
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	require.Equal(suite.T(), "s3cret", failing.Secret)
+
+	ajman, err := domain.NewPort("AEAJM", "Ajman", "52000", "Ajman", "United Arab Emirates", nil, nil, nil, "Ajman", "Asia/Dubai", []string{"AEAJM"})
+	require.NoError(suite.T(), err)
+	rotterdam, err := domain.NewPort("NLRTM", "Rotterdam", "42157", "Rotterdam", "Netherlands", nil, nil, nil, "South Holland", "Europe/Amsterdam", []string{"NLRTM"})
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.portService.CreateOrUpdatePort(ctx, ajman))
+	require.NoError(suite.T(), suite.portService.CreateOrUpdatePort(ctx, rotterdam))
+	require.NoError(suite.T(), suite.portService.CreateOrUpdatePort(ctx, ajman))
+	require.NoError(suite.T(), suite.portService.DeletePortById(ctx, "AEAJM"))
+
+	// only the creation and deletion of the port in the country are
+	// delivered, signed, in the order they were made
+	var kinds []string
+	for len(kinds) < 2 {
+		var delivery received
+		select {
+		case delivery = <-delivered:
+		case <-time.After(5 * time.Second):
+			suite.T().Fatalf("only %v changes were delivered", kinds)
+		}
+
+		kind := delivery.header.Get(services.HeaderWebhookEvent)
+		kinds = append(kinds, kind)
+		require.Equal(suite.T(), filtered.Id, delivery.header.Get(services.HeaderWebhookId))
+		timestamp, err := strconv.ParseInt(delivery.header.Get(services.HeaderWebhookTimestamp), 10, 64)
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), services.SignWebhook(filtered.Secret, timestamp, delivery.body), delivery.header.Get(services.HeaderWebhookSignature))
+
+		var change PortChange
+		require.NoError(suite.T(), json.Unmarshal(delivery.body, &change))
+		require.Equal(suite.T(), kind, change.Kind)
+		require.Equal(suite.T(), "AEAJM", change.Id)
+		require.Equal(suite.T(), "Ajman", change.Port.Name)
+	}
+	require.Equal(suite.T(), []string{"created", "deleted"}, kinds)
+
+	// the failing webhook gets every change, each attempted three times
+	var deadLetters []DeadLetter
+	require.Eventually(suite.T(), func() bool {
+		_, data := execute(webhookServer.ListDeadLetters, http.MethodGet, "/webhooks/dead-letters", "", "")
+		require.NoError(suite.T(), json.Unmarshal(data, &deadLetters))
+		return len(deadLetters) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(suite.T(), attempts, 12)
+	for _, deadLetter := range deadLetters {
+		require.Equal(suite.T(), failing.Id, deadLetter.WebhookId)
+		require.Equal(suite.T(), 3, deadLetter.Attempts)
+		require.Contains(suite.T(), deadLetter.Error, "503")
+	}
+	require.Empty(suite.T(), delivered)
+
+	// secrets are only shown on registration
+	w, data := execute(webhookServer.ListWebhooks, http.MethodGet, "/webhooks", "", "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var listed []Webhook
+	require.NoError(suite.T(), json.Unmarshal(data, &listed))
+	require.Len(suite.T(), listed, 2)
+	require.Equal(suite.T(), filtered.Id, listed[0].Id)
+	require.Empty(suite.T(), listed[0].Secret)
+
+	w, data = execute(webhookServer.ReplaceWebhook, http.MethodPut, "/webhooks/"+filtered.Id, filtered.Id, `{"url": "`+receiver.URL+`/ok"}`)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var replaced Webhook
+	require.NoError(suite.T(), json.Unmarshal(data, &replaced))
+	require.Empty(suite.T(), replaced.Events)
+	require.Equal(suite.T(), filtered.CreatedAt, replaced.CreatedAt)
+
+	w, _ = execute(webhookServer.DeleteWebhook, http.MethodDelete, "/webhooks/"+failing.Id, failing.Id, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	w, _ = execute(webhookServer.GetWebhook, http.MethodGet, "/webhooks/"+failing.Id, failing.Id, "")
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w, _ = execute(webhookServer.ReplaceWebhook, http.MethodPut, "/webhooks/"+failing.Id, failing.Id, `{"url": "`+receiver.URL+`"}`)
+	require.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,296 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+	"github.com/zhenisduissekov/another-dummy-service/internal/log"
+)
+
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateCompleted JobState = "completed"
+	JobStateFailed    JobState = "failed"
+	JobStateCancelled JobState = "cancelled"
+)
+
+const (
+	// maxJobDocumentSize limits the size of a document uploaded as a job.
+	maxJobDocumentSize = 64 << 20
+	// maxJobRecordErrors limits the number of per-record errors a job keeps.
+	maxJobRecordErrors = 1000
+	// maxFinishedJobs is the number of finished jobs kept for status queries.
+	maxFinishedJobs = 100
+	// maxUnfinishedJobs is the number of jobs queued or running at once,
+	// each holding its document in memory.
+	maxUnfinishedJobs = 4
+)
+
+// errTooManyJobs is returned when a job is started while maxUnfinishedJobs
+// have not finished yet.
+var errTooManyJobs = errors.New("too many upload jobs under way")
+
+// errJobsClosed is returned when a job is started after the registry was
+// closed.
+var errJobsClosed = errors.New("upload jobs are shutting down")
+
+// uploadJob is a bulk upload processed in the background.
+type uploadJob struct {
+	mu sync.Mutex
+
+	id         string
+	state      JobState
+	total      int
+	processed  int
+	failed     int
+	skipped    int
+	errors     []RecordError
+	err        string
+	createdAt  time.Time
+	finishedAt time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (j *uploadJob) view() UploadJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job := UploadJob{
+		Id:        j.id,
+		State:     j.state,
+		Total:     j.total,
+		Processed: j.processed,
+		Failed:    j.failed,
+		Skipped:   j.skipped,
+		Errors:    append([]RecordError{}, j.errors...),
+		Error:     j.err,
+		CreatedAt: j.createdAt.UTC().Format(time.RFC3339),
+	}
+	if !j.finishedAt.IsZero() {
+		job.FinishedAt = j.finishedAt.UTC().Format(time.RFC3339)
+	}
+
+	return job
+}
+
+func (j *uploadJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return !j.finishedAt.IsZero()
+}
+
+func (j *uploadJob) start(total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state = JobStateRunning
+	j.total = total
+}
+
+func (j *uploadJob) recordResult(portId string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err == nil {
+		j.processed++
+		return
+	}
+
+	j.failed++
+	if len(j.errors) < maxJobRecordErrors {
+		j.errors = append(j.errors, newRecordErrors(portId, err)...)
+		j.errors = j.errors[:min(len(j.errors), maxJobRecordErrors)]
+	}
+}
+
+func (j *uploadJob) finish(state JobState, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state = state
+	if err != nil {
+		j.err = err.Error()
+	}
+	// whatever has not been processed by now never will be
+	j.skipped = max(0, j.total-j.processed-j.failed)
+	j.finishedAt = time.Now()
+	close(j.done)
+}
+
+// run stores every port of the document, recording the outcome of each one.
+func (j *uploadJob) run(ctx context.Context, service PortService, read portReader, document []byte, validation domain.ValidationMode) {
+	j.start(countDocumentPorts(ctx, read, document))
+
+	portChan := make(chan Port)
+	errChan := make(chan error, 1)
+
+	go func() {
+		err := read(ctx, bytes.NewReader(document), portChan)
+		close(portChan)
+		errChan <- err
+	}()
+
+	for port := range portChan {
+		err := storePort(ctx, service, port, validation)
+		if ctx.Err() != nil {
+			// cancelled, the port counts as skipped
+			continue
+		}
+		j.recordResult(port.Id, err)
+	}
+
+	err := <-errChan
+	switch {
+	case ctx.Err() != nil:
+		j.finish(JobStateCancelled, nil)
+	case err != nil:
+		log.Infof("upload job %s failed: %v", j.id, err)
+		j.finish(JobStateFailed, err)
+	default:
+		j.finish(JobStateCompleted, nil)
+	}
+}
+
+// countDocumentPorts counts the ports of the document by reading it once
+// upfront. A malformed document is counted up to the first error.
+func countDocumentPorts(ctx context.Context, read portReader, document []byte) int {
+	portChan := make(chan Port)
+
+	go func() {
+		_ = read(ctx, bytes.NewReader(document), portChan)
+		close(portChan)
+	}()
+
+	count := 0
+	for range portChan {
+		count++
+	}
+
+	return count
+}
+
+// jobRegistry keeps track of the upload jobs, refusing new ones while too
+// many are under way and evicting the oldest finished ones once there are too
+// many of them. The jobs run under the context of the registry, cancelled
+// when it is closed.
+type jobRegistry struct {
+	mu     sync.Mutex
+	jobs   map[string]*uploadJob
+	order  []string
+	closed bool
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func newJobRegistry() *jobRegistry {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &jobRegistry{
+		jobs:   make(map[string]*uploadJob),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// close cancels the jobs under way and waits for them to finish. Jobs
+// started from then on fail with errJobsClosed.
+func (jr *jobRegistry) close() {
+	jr.mu.Lock()
+	jr.closed = true
+	jr.mu.Unlock()
+
+	jr.cancel()
+	jr.running.Wait()
+}
+
+// start registers a new job and runs it in the background, unless
+// maxUnfinishedJobs are under way, in which case it fails with
+// errTooManyJobs.
+func (jr *jobRegistry) start(service PortService, read portReader, document []byte, validation domain.ValidationMode) (*uploadJob, error) {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+
+	if jr.closed {
+		return nil, errJobsClosed
+	}
+	if jr.unfinished() >= maxUnfinishedJobs {
+		return nil, errTooManyJobs
+	}
+
+	ctx, cancel := context.WithCancel(jr.ctx)
+	job := &uploadJob{
+		id:        uuid.New().String(),
+		state:     JobStateQueued,
+		createdAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	jr.jobs[job.id] = job
+	jr.order = append(jr.order, job.id)
+	jr.evict()
+
+	jr.running.Add(1)
+	go func() {
+		defer jr.running.Done()
+		defer cancel()
+		job.run(ctx, service, read, document, validation)
+	}()
+
+	return job, nil
+}
+
+// unfinished counts the jobs queued or running. Callers must hold the lock.
+func (jr *jobRegistry) unfinished() int {
+	unfinished := 0
+	for _, job := range jr.jobs {
+		if !job.finished() {
+			unfinished++
+		}
+	}
+
+	return unfinished
+}
+
+func (jr *jobRegistry) get(id string) (*uploadJob, bool) {
+	jr.mu.Lock()
+	defer jr.mu.Unlock()
+
+	job, exists := jr.jobs[id]
+	return job, exists
+}
+
+// evict drops the oldest finished jobs above maxFinishedJobs. Callers must hold the lock.
+func (jr *jobRegistry) evict() {
+	finished := 0
+	for _, id := range jr.order {
+		if jr.jobs[id].finished() {
+			finished++
+		}
+	}
+
+	kept := jr.order[:0]
+	for _, id := range jr.order {
+		if finished > maxFinishedJobs && jr.jobs[id].finished() {
+			delete(jr.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	jr.order = kept
+}
@@ -12,4 +12,83 @@ type Port struct {
 	Province    string    `json:"province"`
 	Timezone    string    `json:"timezone"`
 	Unlocs      []string  `json:"unlocs"`
+	DeletedAt   string    `json:"deletedAt,omitempty"`
+}
+
+type PortList struct {
+	Ports      []Port `json:"ports"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type NearbyPort struct {
+	Port       Port    `json:"port"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+type SearchHit struct {
+	Port         Port    `json:"port"`
+	Score        float64 `json:"score"`
+	MatchedField string  `json:"matchedField"`
+}
+
+type Suggestion struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Field string `json:"matchedField"`
+	Value string `json:"matchedValue"`
+}
+
+type PortRevision struct {
+	Version uint64 `json:"version"`
+	At      string `json:"at"`
+	Deleted bool   `json:"deleted"`
+	Port    *Port  `json:"port,omitempty"`
+}
+
+type PortChange struct {
+	Sequence uint64 `json:"sequence"`
+	Kind     string `json:"kind"`
+	Id       string `json:"id"`
+	Version  uint64 `json:"version"`
+	At       string `json:"at"`
+	Port     *Port  `json:"port,omitempty"`
+}
+
+type Webhook struct {
+	Id        string   `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	Countries []string `json:"countries"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+type DeadLetter struct {
+	DeliveryId string     `json:"deliveryId"`
+	WebhookId  string     `json:"webhookId"`
+	URL        string     `json:"url"`
+	Change     PortChange `json:"change"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error"`
+	FailedAt   string     `json:"failedAt"`
+}
+
+type RecordError struct {
+	PortId string `json:"portId"`
+	Field  string `json:"field,omitempty"`
+	Cause  string `json:"cause,omitempty"`
+	Error  string `json:"error"`
+}
+
+type UploadJob struct {
+	Id         string        `json:"id"`
+	State      JobState      `json:"state"`
+	Total      int           `json:"total"`
+	Processed  int           `json:"processed"`
+	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped"`
+	Errors     []RecordError `json:"errors"`
+	Error      string        `json:"error,omitempty"`
+	CreatedAt  string        `json:"createdAt"`
+	FinishedAt string        `json:"finishedAt,omitempty"`
 }
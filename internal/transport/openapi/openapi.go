@@ -0,0 +1,55 @@
+// Package openapi serves the OpenAPI document of the HTTP API, and Swagger
+// UI to browse it. The document is openapi.json, kept by hand: every route
+// registered by the service has to be described in it.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"path"
+
+	swaggerfiles "github.com/swaggo/files/v2"
+)
+
+var (
+	//go:embed openapi.json
+	spec []byte
+	//go:embed docs.html
+	docsPage []byte
+	// initializer points Swagger UI at /openapi.json, in place of the one
+	// of the Swagger UI distribution.
+	//go:embed initializer.js
+	initializer []byte
+)
+
+// Spec returns the OpenAPI document.
+func Spec() []byte {
+	return spec
+}
+
+// ServeSpec answers with the OpenAPI document.
+func ServeSpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(spec)
+}
+
+// Docs serves Swagger UI at /docs and its assets at /docs/{asset}. The
+// assets are embedded, the page works offline.
+func Docs() http.Handler {
+	assets := http.FileServer(http.FS(swaggerfiles.FS))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch asset := path.Base(r.URL.Path); {
+		case r.URL.Path == "/docs":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(docsPage)
+		case asset == "initializer.js":
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			_, _ = w.Write(initializer)
+		default:
+			r = r.Clone(r.Context())
+			r.URL.Path = "/" + asset
+			assets.ServeHTTP(w, r)
+		}
+	})
+}
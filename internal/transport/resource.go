@@ -0,0 +1,328 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	commonerrors "github.com/zhenisduissekov/another-dummy-service/internal/common/errors"
+	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	// mediaTypePort is the media type of a single port, as created by
+	// POST /ports.
+	mediaTypePort = "application/vnd.port+json"
+)
+
+const (
+	// maxPortDocumentSize bounds the body of a single port request.
+	maxPortDocumentSize = 1 << 20
+	// maxPatchAttempts bounds the retries of an unconditional PATCH that
+	// keeps losing the race against other writes of the port.
+	maxPatchAttempts = 3
+)
+
+// portAliasDeprecation is the Deprecation header (RFC 9745) of /port?id=,
+// the date GET /ports/{id} replaced it.
+const portAliasDeprecation = "@1792281600"
+
+// portLocation is the URL of the port resource.
+func portLocation(id string) string {
+	return "/ports/" + url.PathEscape(id)
+}
+
+// deprecatePortAlias marks a response of /port?id= as deprecated in favour
+// of the port resource.
+func deprecatePortAlias(id string, w http.ResponseWriter) {
+	w.Header().Set("Deprecation", portAliasDeprecation)
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, portLocation(id)))
+}
+
+// isSinglePortUpload tells whether a POST /ports creates a single port
+// rather than uploading ports, which it does when the body is sent as
+// mediaTypePort.
+func isSinglePortUpload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == mediaTypePort
+}
+
+// createPort answers a POST /ports holding a single port with 201 Created.
+func (h HttpServer) createPort(w http.ResponseWriter, r *http.Request) {
+	validation, ok := h.validationMode(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := decodePort(w, r, "")
+	if !ok {
+		return
+	}
+
+	p, err := PortHttpToDomain(&port, validation)
+	if err != nil {
+		respondWithUploadError(err, w, r)
+		return
+	}
+
+	err = h.service.CreatePort(r.Context(), p)
+	if err != nil {
+		respondWithUploadError(err, w, r)
+		return
+	}
+
+	h.respondWithWrittenPort(p.Id(), true, w, r)
+}
+
+// ReplacePort stores the port of the body under the id of the path, creating
+// it when needed. If-Match and If-None-Match make the replace conditional.
+func (h HttpServer) ReplacePort(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	validation, ok := h.validationMode(w, r)
+	if !ok {
+		return
+	}
+
+	port, ok := decodePort(w, r, id)
+	if !ok {
+		return
+	}
+
+	p, err := PortHttpToDomain(&port, validation)
+	if err != nil {
+		respondWithUploadError(err, w, r)
+		return
+	}
+
+	record, err := h.service.GetPortRecord(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	err = checkWritePreconditions(r, record)
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	switch {
+	case !isConditional(r):
+		err = h.service.CreateOrUpdatePort(ctx, p)
+	case record == nil:
+		err = h.service.CreatePort(ctx, p)
+	default:
+		err = h.service.UpdatePortIfVersion(ctx, p, record.Version)
+	}
+	if errors.Is(err, domain.ErrAlreadyExists) || errors.Is(err, domain.ErrVersionMismatch) {
+		// the port changed since the preconditions were checked
+		err = commonerrors.NewPreconditionFailedError(err.Error(), slugPreconditionFailed)
+	}
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	h.respondWithWrittenPort(id, record == nil, w, r)
+}
+
+// PatchPort applies a JSON Merge Patch (RFC 7386) to the port, through the
+// mutation methods of the port so that the events of the changes are
+// dispatched. The patched port is stored only if nobody wrote the port in
+// the meantime; without If-Match the patch is then applied again to the
+// newer port.
+func (h HttpServer) PatchPort(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != mediaTypeMergePatch && mediaType != mediaTypeJSON) {
+		server.UnsupportedMediaType("unsupported-media-type", fmt.Errorf("expected %s", mediaTypeMergePatch), w, r)
+		return
+	}
+
+	validation, ok := h.validationMode(w, r)
+	if !ok {
+		return
+	}
+
+	var patch any
+	err = json.NewDecoder(io.LimitReader(r.Body, maxPortDocumentSize)).Decode(&patch)
+	if err != nil {
+		server.BadRequest("invalid json", err, w, r)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = h.service.ModifyPort(ctx, id, func(record *domain.PortRecord) error {
+			return patchPort(r, record, patch, validation)
+		})
+		if errors.Is(err, domain.ErrVersionMismatch) && !isConditional(r) && attempt < maxPatchAttempts {
+			continue
+		}
+		break
+	}
+
+	var fieldErr *domain.FieldError
+	switch {
+	case err == nil:
+		h.respondWithWrittenPort(id, false, w, r)
+	case errors.As(err, &fieldErr):
+		server.BadRequest("port-to-domain", err, w, r)
+	case errors.Is(err, domain.ErrVersionMismatch):
+		server.RespondWithError(commonerrors.NewPreconditionFailedError(err.Error(), slugPreconditionFailed), w, r)
+	case errors.Is(err, domain.ErrNotFound):
+		// a port that does not exist fails If-Match rather than being missing
+		preconditionErr := checkWritePreconditions(r, nil)
+		if preconditionErr != nil {
+			server.RespondWithError(preconditionErr, w, r)
+			return
+		}
+		server.NotFound("port-not-found", err, w, r)
+	default:
+		server.RespondWithError(err, w, r)
+	}
+}
+
+// patchPort checks the preconditions of the PATCH against the stored record
+// and changes its port into the patched one, recording the events of the
+// changes.
+func patchPort(r *http.Request, record *domain.PortRecord, patch any, validation domain.ValidationMode) error {
+	err := checkWritePreconditions(r, record)
+	if err != nil {
+		return err
+	}
+
+	port, err := applyMergePatch(portDomainToHttp(record.Port), patch)
+	if err != nil {
+		return commonerrors.NewIncorrectInputError(err.Error(), "invalid-patch")
+	}
+	if port.Id != record.Port.Id() {
+		return commonerrors.NewIncorrectInputError(fmt.Sprintf("the id of port %s cannot be changed", record.Port.Id()), "port-id-mismatch")
+	}
+
+	patched, err := PortHttpToDomain(&port, validation)
+	if err != nil {
+		return err
+	}
+
+	return record.Port.ChangeTo(patched)
+}
+
+// validationMode reads the validation option of a single port write,
+// answering the request when it is invalid.
+func (h HttpServer) validationMode(w http.ResponseWriter, r *http.Request) (domain.ValidationMode, bool) {
+	validation, err := parseValidationMode(r, h.validation)
+	if err != nil {
+		server.BadRequest("invalid-validation-mode", err, w, r)
+		return validation, false
+	}
+
+	return validation, true
+}
+
+// decodePort reads the port of a single port request. The id may be left
+// out of the body when the path names the port; if both are given they
+// have to match.
+func decodePort(w http.ResponseWriter, r *http.Request, id string) (Port, bool) {
+	var port Port
+	err := json.NewDecoder(io.LimitReader(r.Body, maxPortDocumentSize)).Decode(&port)
+	if err != nil {
+		server.BadRequest("invalid json", err, w, r)
+		return port, false
+	}
+
+	if id == "" {
+		return port, true
+	}
+	if port.Id == "" {
+		port.Id = id
+	}
+	if port.Id != id {
+		server.BadRequest("port-id-mismatch", fmt.Errorf("port id %s does not match the path id %s", port.Id, id), w, r)
+		return port, false
+	}
+
+	return port, true
+}
+
+// respondWithWrittenPort answers a write with the stored port and its ETag,
+// with 201 Created and its Location when the write created the port.
+func (h HttpServer) respondWithWrittenPort(id string, created bool, w http.ResponseWriter, r *http.Request) {
+	record, err := h.service.GetPortRecord(r.Context(), id)
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	w.Header().Set("ETag", portETag(record.Version))
+	if created {
+		w.Header().Set("Location", portLocation(id))
+		server.RespondCreated(portDomainToHttp(record.Port), w, r)
+		return
+	}
+
+	server.RespondOK(portDomainToHttp(record.Port), w, r)
+}
+
+// applyMergePatch patches the port as described by RFC 7386.
+func applyMergePatch(port Port, patch any) (Port, error) {
+	document, err := json.Marshal(port)
+	if err != nil {
+		return port, err
+	}
+
+	var target any
+	err = json.Unmarshal(document, &target)
+	if err != nil {
+		return port, err
+	}
+
+	document, err = json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return port, err
+	}
+
+	var patched Port
+	err = json.Unmarshal(document, &patched)
+	if err != nil {
+		return port, fmt.Errorf("patched port is invalid: %w", err)
+	}
+
+	return patched, nil
+}
+
+// mergePatch is the MergePatch function of RFC 7386: members of a patch
+// object replace those of the target, null ones remove them, and anything
+// else than an object replaces the target as a whole.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,354 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+const (
+	onErrorAbort    = "abort"
+	onErrorContinue = "continue"
+)
+
+const (
+	onConflictSkip   = "skip"
+	onConflictReject = "reject"
+)
+
+// uploadStrategy decides what an upload does with ports that already exist
+// and with stored ports missing from the upload.
+type uploadStrategy string
+
+const (
+	// strategyUpsert creates new ports and updates existing ones.
+	strategyUpsert uploadStrategy = "upsert"
+	// strategyInsertOnly creates new ports and leaves existing ones alone.
+	strategyInsertOnly uploadStrategy = "insert-only"
+	// strategyUpdateOnly updates existing ports and leaves unknown ones out.
+	strategyUpdateOnly uploadStrategy = "update-only"
+	// strategyReplace makes the upload the complete dataset: stored ports
+	// missing from it are deleted once the whole document has been read.
+	strategyReplace uploadStrategy = "replace"
+)
+
+// uploadOptions are the query parameters of POST /ports.
+type uploadOptions struct {
+	// continueOnError keeps the upload going past rejected ports and reports them at the end.
+	continueOnError bool
+	// atomic applies either every port of the upload or none of them.
+	atomic   bool
+	strategy uploadStrategy
+	// rejectConflicts rejects ports the strategy leaves out instead of skipping them.
+	rejectConflicts bool
+	// report answers with an uploadReport instead of the bare port total.
+	report bool
+	// validation decides whether ports not matching the reference tables
+	// are rejected or accepted with warnings.
+	validation domain.ValidationMode
+}
+
+func parseUploadOptions(r *http.Request, validation domain.ValidationMode) (uploadOptions, error) {
+	params := r.URL.Query()
+	opts := uploadOptions{
+		strategy: strategyUpsert,
+	}
+
+	var err error
+	opts.validation, err = parseValidationMode(r, validation)
+	if err != nil {
+		return opts, err
+	}
+
+	switch onError := params.Get("onError"); onError {
+	case "", onErrorAbort:
+	case onErrorContinue:
+		opts.continueOnError = true
+		opts.report = true
+	default:
+		return opts, fmt.Errorf("unknown onError mode %q, expected %q or %q", onError, onErrorAbort, onErrorContinue)
+	}
+
+	if atomic := params.Get("atomic"); atomic != "" {
+		var err error
+		opts.atomic, err = strconv.ParseBool(atomic)
+		if err != nil {
+			return opts, fmt.Errorf("invalid atomic value %q: %w", atomic, err)
+		}
+	}
+
+	if opts.atomic && opts.continueOnError {
+		return opts, errors.New("an atomic upload cannot continue past rejected ports")
+	}
+
+	switch strategy := uploadStrategy(params.Get("strategy")); strategy {
+	case "":
+	case strategyUpsert, strategyInsertOnly, strategyUpdateOnly, strategyReplace:
+		opts.strategy = strategy
+		opts.report = true
+	default:
+		return opts, fmt.Errorf("unknown strategy %q", strategy)
+	}
+
+	switch onConflict := params.Get("onConflict"); onConflict {
+	case "", onConflictSkip:
+	case onConflictReject:
+		opts.rejectConflicts = true
+	default:
+		return opts, fmt.Errorf("unknown onConflict mode %q, expected %q or %q", onConflict, onConflictSkip, onConflictReject)
+	}
+
+	return opts, nil
+}
+
+// uploadReport sums up what an upload did.
+type uploadReport struct {
+	TotalPorts int           `json:"total_ports"`
+	Accepted   int           `json:"accepted"`
+	Rejected   int           `json:"rejected"`
+	Created    int           `json:"created"`
+	Updated    int           `json:"updated"`
+	Unchanged  int           `json:"unchanged"`
+	Skipped    int           `json:"skipped"`
+	Deleted    int           `json:"deleted"`
+	Errors     []RecordError `json:"errors"`
+	// Warnings lists the reference table problems of accepted ports.
+	Warnings []RecordError `json:"warnings,omitempty"`
+}
+
+func (ur *uploadReport) reject(portId string, err error) {
+	ur.Rejected++
+	ur.Errors = append(ur.Errors, newRecordErrors(portId, err)...)
+}
+
+// newRecordErrors describes why a port was rejected, with an error for
+// every failing field naming the domain error behind it.
+func newRecordErrors(portId string, err error) []RecordError {
+	fieldErrs := domain.FieldErrors(err)
+	if len(fieldErrs) == 0 {
+		return []RecordError{{PortId: portId, Error: err.Error()}}
+	}
+
+	recordErrs := make([]RecordError, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		recordErrs = append(recordErrs, RecordError{
+			PortId: portId,
+			Field:  fieldErr.Field,
+			Cause:  fieldErr.Err.Error(),
+			Error:  fieldErr.Error(),
+		})
+	}
+
+	return recordErrs
+}
+
+type portOutcome int
+
+const (
+	outcomeCreated portOutcome = iota
+	outcomeUpdated
+	outcomeUnchanged
+	outcomeSkipped
+)
+
+// portUploader applies the ports of an upload one by one according to the
+// upload options, keeping the report up to date.
+type portUploader struct {
+	service PortService
+	opts    uploadOptions
+	report  uploadReport
+	// batch stages the writes of an atomic upload.
+	batch *domain.PortBatch
+	// staged holds the ports staged in batch, by id, as the batch leaves them.
+	staged map[string]*domain.Port
+	// seen holds the ids of the upload, used by strategyReplace.
+	seen map[string]struct{}
+}
+
+func newPortUploader(service PortService, opts uploadOptions) *portUploader {
+	return &portUploader{
+		service: service,
+		opts:    opts,
+		report:  uploadReport{Errors: []RecordError{}},
+		batch:   domain.NewPortBatch(),
+		staged:  make(map[string]*domain.Port),
+		seen:    make(map[string]struct{}),
+	}
+}
+
+// add applies a single port. It returns an error only when the upload has
+// to be aborted; with continueOnError the port is reported as rejected instead.
+func (u *portUploader) add(ctx context.Context, port Port) error {
+	u.report.TotalPorts++
+	u.seen[port.Id] = struct{}{}
+
+	p, err := PortHttpToDomain(&port, u.opts.validation)
+	var outcome portOutcome
+	if err == nil {
+		outcome, err = u.apply(ctx, p)
+	}
+	if err != nil {
+		if u.opts.continueOnError && ctx.Err() == nil {
+			u.report.reject(port.Id, err)
+			return nil
+		}
+		return err
+	}
+
+	u.report.Accepted++
+	for _, warning := range p.Warnings() {
+		u.report.Warnings = append(u.report.Warnings, newRecordErrors(p.Id(), warning)...)
+	}
+	switch outcome {
+	case outcomeCreated:
+		u.report.Created++
+	case outcomeUpdated:
+		u.report.Updated++
+	case outcomeUnchanged:
+		u.report.Unchanged++
+	case outcomeSkipped:
+		u.report.Skipped++
+	}
+
+	return nil
+}
+
+func (u *portUploader) apply(ctx context.Context, p *domain.Port) (portOutcome, error) {
+	stored, err := u.current(ctx, p.Id())
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return 0, err
+	}
+	exists := err == nil
+
+	switch {
+	case exists && u.opts.strategy == strategyInsertOnly:
+		if u.opts.rejectConflicts {
+			return 0, fmt.Errorf("port %s: %w", p.Id(), domain.ErrAlreadyExists)
+		}
+		return outcomeSkipped, nil
+	case !exists && u.opts.strategy == strategyUpdateOnly:
+		if u.opts.rejectConflicts {
+			return 0, fmt.Errorf("port %s: %w", p.Id(), domain.ErrNotFound)
+		}
+		return outcomeSkipped, nil
+	case exists && stored.Equal(p):
+		return outcomeUnchanged, nil
+	}
+
+	switch {
+	case u.opts.atomic:
+		// staged only, nothing is stored until the whole document has been
+		// read, and the batch fails when the strategy no longer holds by then
+		err = u.stage(p)
+	case u.opts.strategy == strategyInsertOnly:
+		err = u.service.CreatePort(ctx, p)
+		if errors.Is(err, domain.ErrAlreadyExists) && !u.opts.rejectConflicts {
+			// created in the meantime
+			return outcomeSkipped, nil
+		}
+	default:
+		err = u.service.CreateOrUpdatePort(ctx, p)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return outcomeUpdated, nil
+	}
+	return outcomeCreated, nil
+}
+
+// current returns the port as the upload found or staged it so far.
+func (u *portUploader) current(ctx context.Context, id string) (*domain.Port, error) {
+	if port, staged := u.staged[id]; staged {
+		return port, nil
+	}
+
+	return u.service.GetPort(ctx, id)
+}
+
+// stage adds the write of the port to the batch, conditioned on the
+// strategy. A port staged already is upserted: the batch holds it by then.
+func (u *portUploader) stage(p *domain.Port) error {
+	_, staged := u.staged[p.Id()]
+
+	var err error
+	switch {
+	case staged:
+		err = u.batch.Upsert(p)
+	case u.opts.strategy == strategyInsertOnly:
+		err = u.batch.Insert(p)
+	case u.opts.strategy == strategyUpdateOnly:
+		err = u.batch.Update(p)
+	default:
+		err = u.batch.Upsert(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	u.staged[p.Id()] = p
+	return nil
+}
+
+// finish deletes the ports left out of a replacing upload and commits an
+// atomic one.
+func (u *portUploader) finish(ctx context.Context) error {
+	if u.opts.strategy == strategyReplace {
+		missing, err := u.missingPortIds(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range missing {
+			if u.opts.atomic {
+				u.batch.Delete(id)
+			} else {
+				err = u.service.DeletePortById(ctx, id)
+				if errors.Is(err, domain.ErrNotFound) {
+					// deleted in the meantime
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+			u.report.Deleted++
+		}
+	}
+
+	if u.opts.atomic {
+		return u.service.ApplyBatch(ctx, u.batch)
+	}
+
+	return nil
+}
+
+// missingPortIds lists the stored ports that are not part of the upload.
+func (u *portUploader) missingPortIds(ctx context.Context) ([]string, error) {
+	var missing []string
+
+	query := domain.PortQuery{Limit: domain.MaxPageLimit}
+	for {
+		page, err := u.service.ListPorts(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, port := range page.Ports {
+			if _, seen := u.seen[port.Id()]; !seen {
+				missing = append(missing, port.Id())
+			}
+		}
+
+		if page.NextCursor == "" {
+			return missing, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
@@ -5,24 +5,189 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
 )
 
-func portHttpToDomain(port *Port) (*domain.Port, error) {
-	return domain.NewPort(
-		port.Id,
-		port.Name,
-		port.Code,
-		port.City,
-		port.Country,
-		append([]string(nil), port.Alias...),
-		append([]string(nil), port.Regions...),
-		append([]float64(nil), port.Coordinates...),
-		port.Province,
-		port.Timezone,
-		append([]string(nil), port.Unlocs...),
-	)
+// PortHttpToDomain validates the port, rejecting it in strict mode when it
+// does not match the reference tables. The gRPC and GraphQL transports
+// convert their ports through it too.
+func PortHttpToDomain(port *Port, validation domain.ValidationMode) (*domain.Port, error) {
+	return domain.NewPortBuilder().
+		Id(port.Id).
+		Name(port.Name).
+		Code(port.Code).
+		City(port.City).
+		Country(port.Country).
+		Alias(append([]string(nil), port.Alias...)...).
+		Regions(append([]string(nil), port.Regions...)...).
+		Coordinates(append([]float64(nil), port.Coordinates...)).
+		Province(port.Province).
+		Timezone(port.Timezone).
+		Unlocs(append([]string(nil), port.Unlocs...)...).
+		Validation(validation).
+		Build()
+}
+
+func portDomainToHttp(port *domain.Port) Port {
+	httpPort := Port{
+		Id:          port.Id(),
+		Name:        port.Name(),
+		Code:        port.Code(),
+		City:        port.City(),
+		Country:     port.Country(),
+		Alias:       port.Alias(),
+		Regions:     port.Regions(),
+		Coordinates: port.Coordinates(),
+		Province:    port.Province(),
+		Unlocs:      port.Unlocs(),
+		Timezone:    port.Timezone(),
+	}
+	if port.IsDeleted() {
+		httpPort.DeletedAt = port.DeletedAt().UTC().Format(time.RFC3339Nano)
+	}
+
+	return httpPort
+}
+
+func portsDomainToHttp(ports []*domain.Port) []Port {
+	response := make([]Port, 0, len(ports))
+	for _, port := range ports {
+		response = append(response, portDomainToHttp(port))
+	}
+	return response
+}
+
+// defaultNearest is the number of ports GET /ports/nearest returns without k.
+const defaultNearest = 5
+
+func nearbyPortsDomainToHttp(ports []domain.NearbyPort) []NearbyPort {
+	response := make([]NearbyPort, 0, len(ports))
+	for _, port := range ports {
+		response = append(response, NearbyPort{
+			Port:       portDomainToHttp(port.Port),
+			DistanceKm: port.DistanceKm,
+		})
+	}
+	return response
+}
+
+func searchHitsDomainToHttp(hits []domain.SearchHit) []SearchHit {
+	response := make([]SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		response = append(response, SearchHit{
+			Port:         portDomainToHttp(hit.Port),
+			Score:        hit.Score,
+			MatchedField: hit.Field,
+		})
+	}
+	return response
+}
+
+func suggestionsDomainToHttp(suggestions []domain.Suggestion) []Suggestion {
+	response := make([]Suggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		response = append(response, Suggestion(suggestion))
+	}
+	return response
+}
+
+func revisionsDomainToHttp(revisions []domain.PortRevision) []PortRevision {
+	httpRevisions := make([]PortRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		httpRevision := PortRevision{
+			Version: revision.Version,
+			At:      revision.At.UTC().Format(time.RFC3339Nano),
+			Deleted: revision.Deleted,
+		}
+		if revision.Port != nil {
+			port := portDomainToHttp(revision.Port)
+			httpRevision.Port = &port
+		}
+		httpRevisions = append(httpRevisions, httpRevision)
+	}
+
+	return httpRevisions
+}
+
+func changeDomainToHttp(change domain.PortChange) PortChange {
+	httpChange := PortChange{
+		Sequence: change.Sequence,
+		Kind:     string(change.Kind),
+		Id:       change.Id,
+		Version:  change.Version,
+		At:       change.At.UTC().Format(time.RFC3339Nano),
+	}
+	if change.Port != nil {
+		port := portDomainToHttp(change.Port)
+		httpChange.Port = &port
+	}
+
+	return httpChange
+}
+
+// parseIncludeDeleted reads the includeDeleted option of reads, false when missing.
+func parseIncludeDeleted(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("includeDeleted")
+	if raw == "" {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid includeDeleted value %q: %w", raw, err)
+	}
+
+	return includeDeleted, nil
+}
+
+// parseValidationMode reads the validation option of writes, falling back
+// to the mode the server was configured with.
+func parseValidationMode(r *http.Request, def domain.ValidationMode) (domain.ValidationMode, error) {
+	raw := r.URL.Query().Get("validation")
+	if raw == "" {
+		return def, nil
+	}
+
+	return domain.ParseValidationMode(raw)
+}
+
+// parseBoundingBox parses a "minLon,minLat,maxLon,maxLat" box, the order GeoJSON uses.
+func parseBoundingBox(raw string) (domain.BoundingBox, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return domain.BoundingBox{}, fmt.Errorf("expected minLon,minLat,maxLon,maxLat, got %q", raw)
+	}
+
+	values := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return domain.BoundingBox{}, fmt.Errorf("invalid bbox value %q: %w", part, err)
+		}
+		values = append(values, v)
+	}
+
+	return domain.BoundingBox{
+		MinLon: values[0],
+		MinLat: values[1],
+		MaxLon: values[2],
+		MaxLat: values[3],
+	}, nil
+}
+
+// storePort validates the port and creates or updates it.
+func storePort(ctx context.Context, service PortService, port Port, validation domain.ValidationMode) error {
+	p, err := PortHttpToDomain(&port, validation)
+	if err != nil {
+		return err
+	}
+
+	return service.CreateOrUpdatePort(ctx, p)
 }
 
 func readPorts(ctx context.Context, r io.Reader, portChan chan Port) error {
@@ -63,7 +228,11 @@ func readPorts(ctx context.Context, r io.Reader, portChan chan Port) error {
 		}
 
 		port.Id = portId
-		portChan <- port
+		select {
+		case portChan <- port:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	return nil
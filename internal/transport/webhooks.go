@@ -0,0 +1,190 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/zhenisduissekov/another-dummy-service/internal/common/server"
+	"github.com/zhenisduissekov/another-dummy-service/internal/domain"
+)
+
+// maxWebhookDocumentSize caps the body of a webhook registration.
+const maxWebhookDocumentSize = 64 << 10
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (domain.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	DeadLetters(ctx context.Context) ([]domain.DeadLetter, error)
+}
+
+// WebhookServer serves the registration of webhooks and their dead letters.
+type WebhookServer struct {
+	webhooks WebhookService
+}
+
+func NewWebhookServer(webhooks WebhookService) WebhookServer {
+	return WebhookServer{
+		webhooks: webhooks,
+	}
+}
+
+// EncodePortChange encodes a change the way the change stream and the
+// webhooks send it.
+func EncodePortChange(change domain.PortChange) ([]byte, error) {
+	return json.Marshal(changeDomainToHttp(change))
+}
+
+// CreateWebhook registers a webhook. The response is the only one carrying
+// its secret.
+func (h WebhookServer) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.webhooks.CreateWebhook(r.Context(), webhook)
+	if err != nil {
+		respondWithWebhookError(err, w, r)
+		return
+	}
+
+	httpWebhook := webhookDomainToHttp(created)
+	httpWebhook.Secret = created.Secret
+	server.RespondCreated(httpWebhook, w, r)
+}
+
+func (h WebhookServer) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	httpWebhooks := make([]Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		httpWebhooks = append(httpWebhooks, webhookDomainToHttp(webhook))
+	}
+
+	server.RespondOK(httpWebhooks, w, r)
+}
+
+func (h WebhookServer) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := h.webhooks.GetWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithWebhookError(err, w, r)
+		return
+	}
+
+	server.RespondOK(webhookDomainToHttp(webhook), w, r)
+}
+
+// ReplaceWebhook replaces the URL and filters of a webhook. Its secret is
+// kept unless the body has a new one.
+func (h WebhookServer) ReplaceWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+	webhook.Id = mux.Vars(r)["id"]
+
+	updated, err := h.webhooks.UpdateWebhook(r.Context(), webhook)
+	if err != nil {
+		respondWithWebhookError(err, w, r)
+		return
+	}
+
+	server.RespondOK(webhookDomainToHttp(updated), w, r)
+}
+
+func (h WebhookServer) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := h.webhooks.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		respondWithWebhookError(err, w, r)
+		return
+	}
+
+	server.RespondOK("deleted webhook["+id+"] successfully", w, r)
+}
+
+// ListDeadLetters lists the deliveries that failed every attempt, the
+// oldest first.
+func (h WebhookServer) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, err := h.webhooks.DeadLetters(r.Context())
+	if err != nil {
+		server.RespondWithError(err, w, r)
+		return
+	}
+
+	httpDeadLetters := make([]DeadLetter, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		httpDeadLetters = append(httpDeadLetters, DeadLetter{
+			DeliveryId: deadLetter.DeliveryId,
+			WebhookId:  deadLetter.WebhookId,
+			URL:        deadLetter.URL,
+			Change:     changeDomainToHttp(deadLetter.Change),
+			Attempts:   deadLetter.Attempts,
+			Error:      deadLetter.Error,
+			FailedAt:   deadLetter.FailedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	server.RespondOK(httpDeadLetters, w, r)
+}
+
+func decodeWebhook(w http.ResponseWriter, r *http.Request) (domain.Webhook, bool) {
+	var webhook Webhook
+	err := json.NewDecoder(io.LimitReader(r.Body, maxWebhookDocumentSize)).Decode(&webhook)
+	if err != nil {
+		server.BadRequest("invalid json", err, w, r)
+		return domain.Webhook{}, false
+	}
+
+	events := make([]domain.ChangeKind, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, domain.ChangeKind(event))
+	}
+
+	return domain.Webhook{
+		URL:       webhook.URL,
+		Secret:    webhook.Secret,
+		Events:    events,
+		Countries: webhook.Countries,
+	}, true
+}
+
+func respondWithWebhookError(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidWebhook):
+		server.BadRequest("invalid-webhook", err, w, r)
+	case errors.Is(err, domain.ErrNotFound):
+		server.NotFound("webhook-not-found", err, w, r)
+	default:
+		server.RespondWithError(err, w, r)
+	}
+}
+
+// webhookDomainToHttp converts the webhook, leaving its secret out.
+func webhookDomainToHttp(webhook domain.Webhook) Webhook {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+
+	return Webhook{
+		Id:        webhook.Id,
+		URL:       webhook.URL,
+		Events:    events,
+		Countries: append([]string{}, webhook.Countries...),
+		CreatedAt: webhook.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}